@@ -0,0 +1,129 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// logger name of alert events, they are not counted by alert destinations
+const alertLoggerName = loggerName + ".alert"
+
+// AlertTransport counts the events of its level and up, ERROR by default, in
+// a sliding window. When more than Threshold events fall into the window it
+// logs a single alert event to all destinations, and posts it to Webhook if
+// set. It alerts again once the count fell to the threshold and rose above:
+//
+//	senlog.AddDestination("alert", sentry.ClientOptions{
+//		Transport: senlog.NewAlertTransport(50, time.Minute), // > 50 errors/min
+//	})
+type AlertTransport struct {
+	Logger
+
+	Threshold int
+	Window    time.Duration
+	Webhook   string // optional URL receiving alerts as JSON with a "text" field, e.g. a Slack webhook
+
+	mu       sync.Mutex
+	times    []time.Time // timestamps of the events in the window, oldest first
+	breached bool
+}
+
+func NewAlertTransport(threshold int, window time.Duration) *AlertTransport {
+
+	t := &AlertTransport{Threshold: threshold, Window: window}
+	t.SetLogLevel(ERROR)
+	return t
+}
+
+func (t *AlertTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *AlertTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		// audit and debug sampled events pass the level of Call, they aren't errors
+		if ev.Logger == alertLoggerName || senlogLevels[ev.Level] < t.MinLogLevel() {
+			return
+		}
+
+		t.mu.Lock()
+		start := ev.Timestamp.Add(-t.Window)
+		n := 0
+		for n < len(t.times) && !t.times[n].After(start) {
+			n++
+		}
+		t.times = append(t.times[n:], ev.Timestamp)
+		count := len(t.times)
+
+		alert := count > t.Threshold && !t.breached
+		t.breached = count > t.Threshold
+		t.mu.Unlock()
+
+		if alert { // outside the lock, the alert event reaches this transport too
+			t.alert(count, ev)
+		}
+	}, ev)
+}
+
+func (t *AlertTransport) alert(count int, last *sentry.Event) {
+
+	msg := fmt.Sprintf("Error threshold breached: %d events in %s, more than %d", count, t.Window, t.Threshold)
+	x := Cxt("alert").
+		Set("count", count).
+		Set("threshold", t.Threshold).
+		Set("window", t.Window.String()).
+		Set("last_message", last.Message)
+
+	captureWith(context.Background(), ERROR, nil, x, msg, func(r *Record) {
+		r.Logger = alertLoggerName
+		r.sentryPart().Fingerprint = []string{"senlog-alert", t.Window.String()}
+	})
+
+	if t.Webhook == "" {
+		return
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"text":      msg,
+		"count":     count,
+		"threshold": t.Threshold,
+		"window":    t.Window.String(),
+	})
+
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+
+		client := http.Client{Timeout: FlushTimeout}
+		resp, err := client.Post(t.Webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			diagnose(fmt.Errorf("could not post alert: %w", err))
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			diagnose(fmt.Errorf("could not post alert: webhook responded %s", resp.Status))
+		}
+	}()
+}
+
+func (t *AlertTransport) Flush(time.Duration) bool {
+	return true
+}
@@ -0,0 +1,38 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestAlertCountsOnlyItsLevel(t *testing.T) {
+
+	quiet(t)
+	alert := NewAlertTransport(0, time.Minute)
+
+	audit := &sentry.Event{Level: sentry.LevelInfo, Logger: auditLoggerName, Timestamp: time.Now()}
+	sampled := &sentry.Event{Level: sentry.LevelDebug, Tags: map[string]string{debugSampledTag: "true"}, Timestamp: time.Now()}
+	alert.SendEvent(audit)
+	alert.SendEvent(sampled)
+
+	if alert.breached || len(alert.times) != 0 {
+		t.Errorf("counted %d events below ERROR", len(alert.times))
+	}
+
+	alert.SendEvent(&sentry.Event{Level: sentry.LevelError, Timestamp: time.Now()})
+	if !alert.breached {
+		t.Error("ERROR event not counted")
+	}
+}
@@ -0,0 +1,87 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// logger name of audit events, transports log them regardless of their level
+const auditLoggerName = loggerName + ".audit"
+
+// SetAuditSink flags a destination as sink for Audit events, other
+// destinations never receive them
+func SetAuditSink(destinationKey string, on bool) {
+
+	d, exists := lookup(destinationKey)
+	if !exists { // destination doesn't exist
+		notice("cannot set audit sink, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	if on {
+		atomic.StoreInt32(&d.audit, 1)
+	} else {
+		atomic.StoreInt32(&d.audit, 0)
+	}
+}
+
+// Audit logs who (actor) did what (action) to which object (target) to all
+// audit sinks, independent of log levels. action, actor and target are
+// required. An error is returned if they are missing or there is no audit sink.
+func Audit(action, actor, target string, fields map[string]interface{}) error {
+
+	switch {
+	case action == "":
+		return errors.New("audit: action is required")
+	case actor == "":
+		return errors.New("audit: actor is required")
+	case target == "":
+		return errors.New("audit: target is required")
+	}
+
+	if atomic.LoadInt32(&shutdown) == 1 {
+		return errors.New("audit: senlog is shut down")
+	}
+
+	x := Cxt("audit").Set("action", action).Set("actor", actor).Set("target", target)
+	for k, v := range fields {
+		switch k {
+		case "action", "actor", "target": // can't be overwritten by fields
+		default:
+			x = x.Set(k, v)
+		}
+	}
+
+	r := &Record{
+		ID:     string(newEventID()),
+		Time:   now(),
+		Level:  INFO,
+		Logger: auditLoggerName,
+		Msg:    action,
+	}
+	r.setContexts(x.contexts())
+
+	sinks := 0
+	for _, d := range destinations() {
+		if atomic.LoadInt32(&d.audit) == 1 {
+			d.send(context.Background(), r)
+			sinks++
+		}
+	}
+
+	if sinks == 0 {
+		return errors.New("audit: no audit sink destination")
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync/atomic"
+)
+
+// Overflow is what happens to an event logged while a destination's queue is full
+type Overflow int
+
+const (
+	Block      Overflow = iota // logging waits for room, no event is lost
+	DropOldest                 // the oldest queued event is dropped
+	DropNewest                 // the event logged is dropped
+)
+
+// Backpressure configures the queue of a destination under parallel dispatch,
+// see SetParallelDispatch. Dropped events are counted as dropped.
+type Backpressure struct {
+	Overflow Overflow
+
+	// OnHighWater is called, in its own goroutine, when the queue fills up to
+	// the HighWater share of its size, e.g. 0.8, and again after it was below.
+	HighWater   float64
+	OnHighWater func(destinationKey string, queued int, size int)
+}
+
+// SetBackpressure sets the overflow policy and high-water mark of a
+// destination's queue, the default blocks. Ordered parallel dispatch, see
+// SetOrdering, sends concurrently instead of queueing, DropOldest drops the
+// newest event there.
+func SetBackpressure(destinationKey string, b Backpressure) {
+
+	d, exists := lookup(destinationKey)
+	if !exists {
+		notice("cannot set backpressure, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	d.mu.Lock()
+	d.backpressure = b
+	d.mu.Unlock()
+}
+
+func (d *destination) overflow() Backpressure {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.backpressure
+}
+
+// puts r into the queue by overflow. If the queue is full and blocks, it
+// returns a func waiting for room, see dispatch.
+func (d *destination) enqueue(q chan *Record, r *Record, overflow Overflow) (wait func()) {
+
+	queued.Add(1)
+	pending.Add(1)
+
+	switch overflow {
+	case DropNewest:
+		select {
+		case q <- r:
+		default:
+			d.dequeued(r, DroppedByBackpressure)
+			return nil
+		}
+	case DropOldest:
+		for sent := false; !sent; {
+			select {
+			case q <- r:
+				sent = true
+			default:
+				select {
+				case old := <-q:
+					d.dequeued(old, DroppedByBackpressure)
+				default: // the sender took it
+				}
+			}
+		}
+	default:
+		select {
+		case q <- r:
+		default:
+			return func() {
+				select {
+				case q <- r:
+					d.highWater(d.overflow(), len(q), cap(q))
+					d.discardIfRemoved(q)
+				case <-d.stop:
+					d.dequeued(r, DroppedByRemoval)
+				}
+			}
+		}
+	}
+
+	d.highWater(d.overflow(), len(q), cap(q))
+	d.discardIfRemoved(q)
+	return nil
+}
+
+// drops the events of q if the destination was removed meanwhile: its
+// goroutine may have discarded the queue and ended before r was put into it
+func (d *destination) discardIfRemoved(q chan *Record) {
+
+	select {
+	case <-d.stop:
+		d.discard(q)
+	default:
+	}
+}
+
+// accounts an event dropped from or instead of the queue
+func (d *destination) dequeued(r *Record, reason DropReason) {
+
+	d.drop(r, reason)
+	queued.Done()
+	pending.Done()
+}
+
+// takes a send slot by overflow and sends r in its turn. If no slot is free
+// and overflow blocks, it returns a func waiting for one, see dispatch.
+func (d *destination) acquire(slots chan struct{}, r *Record, t turn, overflow Overflow) (wait func()) {
+
+	select {
+	case slots <- struct{}{}:
+		d.sendInSlot(slots, r, t)
+		return nil
+	default:
+	}
+
+	if overflow != Block {
+		d.drop(r, DroppedByBackpressure)
+		d.skip(t)
+		return nil
+	}
+
+	return func() {
+		select {
+		case slots <- struct{}{}:
+			d.sendInSlot(slots, r, t)
+		case <-d.stop:
+			d.drop(r, DroppedByRemoval)
+			d.skip(t)
+		}
+	}
+}
+
+// calls OnHighWater when n crosses the high-water mark of size
+func (d *destination) highWater(b Backpressure, n int, size int) {
+
+	if b.OnHighWater == nil || b.HighWater <= 0 {
+		return
+	}
+
+	if float64(n) < b.HighWater*float64(size) {
+		atomic.StoreInt32(&d.aboveHighWater, 0)
+		return
+	}
+
+	if atomic.CompareAndSwapInt32(&d.aboveHighWater, 0, 1) {
+		go b.OnHighWater(d.key, n, size)
+	}
+}
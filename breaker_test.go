@@ -0,0 +1,78 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestErrorResponsesTripTheBreaker(t *testing.T) {
+
+	quiet(t)
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://key@", 1) + "/1"
+	addTestDestination(t, "rejecting", sentry.ClientOptions{Dsn: dsn, Transport: NewSentryTransport(DEBUG)})
+	SetCircuitBreaker("rejecting", 2, time.Minute)
+	ResetStats()
+	atomic.StoreInt32(&requests, 0) // the setup notice was sent
+
+	for i := 0; i < 3; i++ {
+		Except("console").INF("rejected")
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("%d requests, want 2 before the breaker opened", n)
+	}
+	if stats := Stats()["rejecting"]; stats.Failed != 2 || stats.Dropped != 3 {
+		t.Errorf("stats = %+v, want 2 failed and 3 dropped", stats)
+	}
+}
+
+func TestTimedOutReportingSendCountsOnce(t *testing.T) {
+
+	quiet(t)
+	var hang int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&hang) == 1 {
+			<-release
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://key@", 1) + "/1"
+	addTestDestination(t, "slow", sentry.ClientOptions{Dsn: dsn, Transport: NewSentryTransport(DEBUG)})
+	SetSendTimeout("slow", 10*time.Millisecond)
+	ResetStats()
+	atomic.StoreInt32(&hang, 1)
+
+	Except("console").INF("timed out")
+	close(release) // the request fails after the send timed out
+	waitPending(time.Second)
+
+	if stats := Stats()["slow"]; stats.Failed != 1 || stats.Dropped != 1 {
+		t.Errorf("stats = %+v, want 1 failed and 1 dropped", stats)
+	}
+}
@@ -0,0 +1,149 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const (
+	DefaultMaxEventSize    = 1000 * 1000 // bytes of event JSON accepted by Sentry
+	DefaultMaxStringLength = 8192        // Sentry's limit of messages
+	keptFrames             = 50          // newest stack frames kept of an oversized event
+)
+
+// trimmed copy of ev fitting maxSize bytes of JSON, and the bytes trimmed.
+// Oversized events lose, until they fit: the tail of long strings, old
+// breadcrumbs, old stack frames and the largest context groups.
+func fitEvent(ev *sentry.Event, maxSize int, maxString int) (*sentry.Event, int) {
+
+	if maxSize <= 0 {
+		maxSize = DefaultMaxEventSize
+	}
+	if maxString <= 0 {
+		maxString = DefaultMaxStringLength
+	}
+
+	size := eventSize(ev)
+	if size <= maxSize {
+		return ev, 0
+	}
+
+	c := copyEvent(ev)
+
+	c.Message = truncate(c.Message, maxString)
+	c.Exception = append([]sentry.Exception(nil), c.Exception...)
+	for i := range c.Exception {
+		c.Exception[i].Value = truncate(c.Exception[i].Value, maxString)
+	}
+	for name, fields := range c.Contexts {
+		c.Contexts[name] = truncateValue(fields, maxString)
+	}
+	for k, v := range c.Extra {
+		c.Extra[k] = truncateValue(v, maxString)
+	}
+
+	for len(c.Breadcrumbs) > 0 && eventSize(c) > maxSize {
+		c.Breadcrumbs = c.Breadcrumbs[len(c.Breadcrumbs)/2:] // oldest first
+		if len(c.Breadcrumbs) == 1 {
+			c.Breadcrumbs = nil
+		}
+	}
+
+	if eventSize(c) > maxSize {
+		for i := range c.Exception {
+			c.Exception[i].Stacktrace = newestFrames(c.Exception[i].Stacktrace)
+		}
+		c.Threads = append([]sentry.Thread(nil), c.Threads...)
+		for i := range c.Threads {
+			c.Threads[i].Stacktrace = newestFrames(c.Threads[i].Stacktrace)
+		}
+	}
+
+	for _, name := range groupsBySize(c.Contexts) {
+		if eventSize(c) <= maxSize {
+			break
+		}
+		c.Contexts[name] = map[string]interface{}{"senlog.trimmed": "context too large"}
+	}
+
+	trimmed := size - eventSize(c)
+	c.Extra["senlog.trimmed"] = trimmed
+	diagnose(fmt.Errorf("event %s trimmed by %d of %d bytes to fit %d bytes", ev.EventID, trimmed, size, maxSize))
+
+	return c, trimmed
+}
+
+func eventSize(ev *sentry.Event) int {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// s cut to max bytes with a marker of the cut length
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", s[:max], len(s)-max)
+}
+
+// v with long strings truncated, maps and slices of it are copied
+func truncateValue(v interface{}, max int) interface{} {
+
+	switch v := v.(type) {
+	case string:
+		return truncate(v, max)
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			c[k] = truncateValue(value, max)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, value := range v {
+			c[i] = truncateValue(value, max)
+		}
+		return c
+	}
+	return v
+}
+
+func newestFrames(st *sentry.Stacktrace) *sentry.Stacktrace {
+
+	if st == nil || len(st.Frames) <= keptFrames {
+		return st
+	}
+	c := *st
+	c.Frames = st.Frames[len(st.Frames)-keptFrames:] // oldest first
+	return &c
+}
+
+// names of the context groups, the largest first
+func groupsBySize(contexts map[string]interface{}) []string {
+
+	sizes := make(map[string]int, len(contexts))
+	names := make([]string, 0, len(contexts))
+	for name, fields := range contexts {
+		b, _ := json.Marshal(fields)
+		sizes[name] = len(b)
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return sizes[names[i]] > sizes[names[j]] })
+	return names
+}
@@ -0,0 +1,67 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "sync/atomic"
+
+// EventChain links related events, e.g. the attempts of a retried call or a
+// request and its response, by a shared correlation ID, see Chain
+type EventChain struct {
+	id      string
+	attempt int32
+}
+
+// Chain returns a chain of events with the correlation ID id, a new one if id
+// is empty. Its contexts set the fields correlation_id and attempt:
+//
+//	chain := senlog.Chain("")
+//	for {
+//		x := chain.Next()
+//		x.Set("url", url).INF("Request")
+//		resp, err := client.Do(req)
+//		if err == nil {
+//			x.Set("status", resp.StatusCode).INF("Response")
+//			break
+//		}
+//		x.ERR(err, "Request failed")
+//	}
+func Chain(id string) *EventChain {
+
+	if id == "" {
+		id = string(newEventID())
+	}
+	return &EventChain{id: id}
+}
+
+// ID returns the correlation ID of the chain
+func (c *EventChain) ID() string {
+	return c.id
+}
+
+// Next starts the next attempt, the first is 1, and returns its context
+func (c *EventChain) Next() *Context {
+	return c.context(atomic.AddInt32(&c.attempt, 1))
+}
+
+// Current returns the context of the current attempt, e.g. for the response
+// of a request, the first attempt if Next wasn't called yet
+func (c *EventChain) Current() *Context {
+
+	attempt := atomic.LoadInt32(&c.attempt)
+	if attempt == 0 {
+		attempt = 1
+	}
+	return c.context(attempt)
+}
+
+func (c *EventChain) context(attempt int32) *Context {
+	return Set("correlation_id", c.id).Set("attempt", int(attempt))
+}
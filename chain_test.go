@@ -0,0 +1,86 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestChainLinksAttempts(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	chain := Chain("")
+	if chain.ID() == "" {
+		t.Fatal("chain without correlation ID")
+	}
+	chain.Current().INF("before")
+	chain.Next().INF("first")
+	chain.Next().WRN("second")
+	chain.Current().INF("response")
+
+	want := map[string]int{"before": 1, "first": 1, "second": 2, "response": 2}
+	for _, ev := range rec.Events() {
+		attempt, ok := want[ev.Message]
+		if !ok {
+			continue
+		}
+		delete(want, ev.Message)
+		if id, _ := field(ev, "Default Context", "correlation_id"); id != chain.ID() {
+			t.Errorf("%s: correlation_id %v, want %s", ev.Message, id, chain.ID())
+		}
+		if got, _ := field(ev, "Default Context", "attempt"); got != attempt {
+			t.Errorf("%s: attempt %v, want %d", ev.Message, got, attempt)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("not sent: %v", want)
+	}
+}
+
+func TestChainOfGivenID(t *testing.T) {
+
+	if id := Chain("order-7").ID(); id != "order-7" {
+		t.Errorf("ID %q, want order-7", id)
+	}
+	if Chain("").ID() == Chain("").ID() {
+		t.Error("new chains share a correlation ID")
+	}
+}
+
+func TestChainAttemptsAreDistinctAcrossGoroutines(t *testing.T) {
+
+	chain := Chain("")
+	var mu sync.Mutex
+	seen := make(map[interface{}]bool)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v := chain.Next().contexts()["Default Context"].(map[string]interface{})["attempt"]
+			mu.Lock()
+			seen[v] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(seen) != 50 {
+		t.Errorf("%d distinct attempts, want 50", len(seen))
+	}
+}
@@ -0,0 +1,103 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// sources of changes. senlog installs no signal handlers, a level change on a
+// signal is made by the application's handler calling e.g. SetLogLevel, so it
+// is recorded as ChangedByAPI with the handler as Caller.
+const (
+	ChangedByAPI    = "api"    // a senlog function, e.g. SetLogLevel
+	ChangedByConfig = "config" // ApplyConfig, e.g. with remote configuration
+)
+
+// max changes kept by Changes, older ones are dropped
+const maxChanges = 1000
+
+// Change is a runtime change of a destination's level or of routing, see Changes
+type Change struct {
+	Time        time.Time
+	Setting     string // level, child_level (see MultiTransport.Add), groups, profile, destination (added or removed)
+	Destination string // key of the destination, empty for profile changes
+	From, To    string
+	Source      string // ChangedByAPI or ChangedByConfig
+	Caller      string // file:line of the call outside senlog making the change
+}
+
+var (
+	changesMu sync.Mutex
+	changes   []Change
+)
+
+// Changes returns the recent level and routing changes, oldest first, e.g. to
+// find out since when a destination logs at DEBUG. CurrentConfig returns them
+// too. Every change is also sent to the audit sinks as action
+// senlog.<setting>_changed with the source as actor and the destination or
+// setting as target, see Audit.
+func Changes() []Change {
+
+	changesMu.Lock()
+	defer changesMu.Unlock()
+	return append([]Change(nil), changes...)
+}
+
+// records a change unless from equals to. Returns false if nothing changed,
+// callers audit the change once their locks are released.
+func recordChange(source string, setting string, destination string, from string, to string) (Change, bool) {
+
+	if from == to {
+		return Change{}, false
+	}
+
+	c := Change{
+		Time:        now(),
+		Setting:     setting,
+		Destination: destination,
+		From:        from,
+		To:          to,
+		Source:      source,
+		Caller:      caller(),
+	}
+
+	changesMu.Lock()
+	if len(changes) == maxChanges {
+		changes = append(changes[:0], changes[1:]...)
+	}
+	changes = append(changes, c)
+	changesMu.Unlock()
+
+	return c, true
+}
+
+// sends changes to the audit sinks, if there are none they are only kept
+func auditChanges(cs ...Change) {
+
+	for _, c := range cs {
+		target := c.Destination
+		if target == "" {
+			target = c.Setting
+		}
+		_ = Audit("senlog."+c.Setting+"_changed", c.Source, target, map[string]interface{}{
+			"from":   c.From,
+			"to":     c.To,
+			"caller": c.Caller,
+		})
+	}
+}
+
+func groupsString(groups []string) string {
+	return strings.Join(groups, ",")
+}
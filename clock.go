@@ -0,0 +1,36 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var clock atomic.Value // func() time.Time
+
+// SetClock sets the clock of event timestamps and job durations, e.g. a fixed
+// time for deterministic test output or virtual time of a simulation. nil
+// restores time.Now. Timeouts and circuit breakers always use the real time.
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	clock.Store(now)
+}
+
+// current time of the clock
+func now() time.Time {
+	if c, ok := clock.Load().(func() time.Time); ok {
+		return c()
+	}
+	return time.Now()
+}
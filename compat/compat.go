@@ -0,0 +1,82 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package compat keeps the global senlog API, DBG, INF, WRN, ERR, FTL, Set,
+// Cxt and the destination functions, stable across major versions. Code
+// importing compat instead of senlog keeps compiling when the core API is
+// redesigned, and can move to the new API one call site at a time:
+//
+//	import senlog "github.com/ejazmughal/senlog/compat"
+//
+//	senlog.Set("user", id).INF("Logged in")
+//
+// The functions delegate to the current senlog package.
+package compat
+
+import (
+	"context"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/getsentry/sentry-go"
+)
+
+// log levels, untyped ints
+const (
+	DEBUG = senlog.DEBUG
+	INFO  = senlog.INFO
+	WARN  = senlog.WARN
+	ERROR = senlog.ERROR
+	FATAL = senlog.FATAL
+)
+
+const FlushTimeout = senlog.FlushTimeout
+
+// Context holds the fields of an event, see senlog.Context
+type Context = senlog.Context
+
+func AddDestination(key string, options sentry.ClientOptions) error {
+	return senlog.AddDestination(key, options)
+}
+
+func RemoveDestination(key string) {
+	senlog.RemoveDestination(key)
+}
+
+func SetLogLevel(destinationKey string, minLevel int) {
+	senlog.SetLogLevel(destinationKey, senlog.Level(minLevel))
+}
+
+func Cxt(k string) *Context                { return senlog.Cxt(k) }
+func Set(k string, v interface{}) *Context { return senlog.Set(k, v) }
+func Except(keys ...string) *Context       { return senlog.Except(keys...) }
+func At(level int) *Context                { return senlog.At(senlog.Level(level)) }
+func Enabled(level int) bool               { return senlog.Enabled(senlog.Level(level)) }
+
+func DBG(v ...interface{})          { senlog.DBG(v...) }
+func INF(v ...interface{})          { senlog.INF(v...) }
+func WRN(v ...interface{})          { senlog.WRN(v...) }
+func ERR(e error, v ...interface{}) { senlog.ERR(e, v...) }
+func FTL(e error, v ...interface{}) { senlog.FTL(e, v...) }
+
+func DBGCtx(ctx context.Context, v ...interface{})          { senlog.DBGCtx(ctx, v...) }
+func INFCtx(ctx context.Context, v ...interface{})          { senlog.INFCtx(ctx, v...) }
+func WRNCtx(ctx context.Context, v ...interface{})          { senlog.WRNCtx(ctx, v...) }
+func ERRCtx(ctx context.Context, e error, v ...interface{}) { senlog.ERRCtx(ctx, e, v...) }
+
+func DBGkv(msg string, kv ...interface{})          { senlog.DBGkv(msg, kv...) }
+func INFkv(msg string, kv ...interface{})          { senlog.INFkv(msg, kv...) }
+func WRNkv(msg string, kv ...interface{})          { senlog.WRNkv(msg, kv...) }
+func ERRkv(e error, msg string, kv ...interface{}) { senlog.ERRkv(e, msg, kv...) }
+func FTLkv(e error, msg string, kv ...interface{}) { senlog.FTLkv(e, msg, kv...) }
+
+// Shutdown flushes and closes all destinations, see senlog.Shutdown
+func Shutdown(ctx context.Context) (dropped uint64, err error) {
+	return senlog.Shutdown(ctx)
+}
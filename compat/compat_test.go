@@ -0,0 +1,57 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package compat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/ejazmughal/senlog/senlogtest"
+)
+
+func TestCompatDelegatesToSenlog(t *testing.T) {
+
+	if !Enabled(FATAL) {
+		t.Error("FATAL not enabled")
+	}
+
+	lvl := FATAL + 1 // a level held in an int
+	SetLogLevel("console", lvl)
+	defer SetLogLevel("console", DEBUG)
+	if got := senlog.CurrentConfig().Destinations["console"].MinLevel; got != senlog.Level(lvl) {
+		t.Errorf("console at %v, want %v", got, senlog.Level(lvl))
+	}
+
+	out, err := senlogtest.Render(senlog.JSONFormat, func() {
+		Set("user", "ada").INF("Logged in")
+		Cxt("order").Set("id", 7).WRN("Order late")
+		ERR(errors.New("timeout"), "Payment failed")
+		INFkv("Paid", "amount", 12)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d events, want 4:\n%s", len(lines), out)
+	}
+	for i, want := range []string{`"Logged in"`, `"Order late"`, `"Payment failed"`, `"Paid"`} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("event %d is %s, want %s", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(lines[0], `"ada"`) || !strings.Contains(lines[3], `12`) {
+		t.Errorf("fields missing:\n%s", out)
+	}
+}
@@ -0,0 +1,406 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config is a snapshot of senlog's settings, see CurrentConfig and
+// ApplyConfig. Settings that are functions, e.g. the clock, the field
+// normalizer or hooks, are not part of it.
+type Config struct {
+	ReportCaller     bool
+	AttachStacktrace bool
+	StrictMode       bool
+	NilErrorMessage  string
+	DuplicateKeys    DuplicateKeys
+	ContextNaming    ContextNaming
+	SetupVerbosity   SetupVerbosity
+	FieldAliases     map[string]string
+
+	CrashDir        string
+	CrashProfiles   bool
+	CrashCPUProfile time.Duration
+	ReplayMaxAge    time.Duration
+	ReplayStale     StaleEvents
+
+	ParallelDispatch int
+	Ordering         bool
+	PriorityLanes    bool
+	DeadlineFields   bool
+	DryRun           bool     // read only, see SetDryRun
+	Changes          []Change // read only, recent level and routing changes, see Changes
+
+	Profiles      map[string][]string // groups by routing profile
+	ActiveProfile string
+
+	Destinations map[string]DestinationConfig
+}
+
+// DestinationConfig are the settings of a destination. Destinations are added
+// with their transport by AddDestination, a Config only changes their settings.
+type DestinationConfig struct {
+	Transport   string // type of the transport, read only
+	MinLevel    Level  // 0 if the transport has no level
+	SendTimeout time.Duration
+	MaxFailures int
+	Cooldown    time.Duration
+	AuditSink   bool
+	PIIPolicy   PIIPolicy
+	SampleRate  float64
+	Groups      []string
+	Shadow      string // key of the primary destination, empty if no shadow
+	Overflow    Overflow
+	HighWater   float64
+}
+
+// package settings, immutable once stored: a change stores a modified copy, so
+// readers see all or nothing of these. Field aliases, profiles and destination
+// settings are stored apart, a reader may see some of them changed by a running
+// ApplyConfig and others not yet.
+type settings struct {
+	reportCaller     bool
+	attachStacktrace bool
+	strictMode       bool
+	nilErrorMessage  string // empty for DefaultNilErrorMessage
+	duplicateKeys    DuplicateKeys
+	contextNaming    ContextNaming
+	setupVerbosity   SetupVerbosity
+
+	crashDir        string        // directory for crash markers, empty disables them
+	crashProfiles   bool          // write profiles beside crash markers
+	crashCPUProfile time.Duration // length of the CPU profile, 0 skips it
+	replay          replayPolicy
+
+	parallelDispatch int // queue size of each destination, 0 dispatches synchronously
+	ordering         bool
+	priorityLanes    bool
+	deadlineFields   bool
+}
+
+var (
+	defaultSettings = settings{priorityLanes: true, deadlineFields: true}
+	currentSettings atomic.Value // *settings
+
+	// serializes changes of the settings, ApplyConfig and CurrentConfig, a
+	// snapshot never sees half of a config
+	configMu sync.Mutex
+)
+
+func loadSettings() *settings {
+
+	if s, ok := currentSettings.Load().(*settings); ok {
+		return s
+	}
+	return &defaultSettings
+}
+
+// stores a copy of the settings modified by change
+func updateSettings(change func(s *settings)) {
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	s := *loadSettings()
+	change(&s)
+	currentSettings.Store(&s)
+}
+
+// CurrentConfig returns a snapshot of the current settings
+func CurrentConfig() Config {
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	s := loadSettings()
+	c := Config{
+		ReportCaller:     s.reportCaller,
+		AttachStacktrace: s.attachStacktrace,
+		StrictMode:       s.strictMode,
+		NilErrorMessage:  nilErrorMessage(s.nilErrorMessage),
+		DuplicateKeys:    s.duplicateKeys,
+		ContextNaming:    s.contextNaming,
+		SetupVerbosity:   s.setupVerbosity,
+		FieldAliases:     make(map[string]string),
+		CrashDir:         s.crashDir,
+		CrashProfiles:    s.crashProfiles,
+		CrashCPUProfile:  s.crashCPUProfile,
+		ReplayMaxAge:     s.replay.maxAge,
+		ReplayStale:      s.replay.stale,
+		ParallelDispatch: s.parallelDispatch,
+		Ordering:         s.ordering,
+		PriorityLanes:    s.priorityLanes,
+		DeadlineFields:   s.deadlineFields,
+		DryRun:           dryRunReport() != nil,
+		Changes:          Changes(),
+		Profiles:         make(map[string][]string),
+		Destinations:     make(map[string]DestinationConfig),
+	}
+
+	for from, to := range currentFieldNames().aliases {
+		c.FieldAliases[from] = to
+	}
+
+	profilesMu.Lock()
+	for name, groups := range profiles {
+		c.Profiles[name] = append([]string(nil), groups...)
+	}
+	c.ActiveProfile = activeProfile
+	profilesMu.Unlock()
+
+	for _, d := range destinations() {
+		c.Destinations[d.key] = d.config()
+	}
+
+	return c
+}
+
+func (d *destination) config() DestinationConfig {
+
+	tr := d.hub.Client().Transport
+
+	c := DestinationConfig{
+		Transport:  fmt.Sprintf("%T", tr),
+		AuditSink:  atomic.LoadInt32(&d.audit) == 1,
+		PIIPolicy:  d.piiPolicy(),
+		SampleRate: math.Float64frombits(atomic.LoadUint64(&d.sampleRate)),
+	}
+	if l, ok := tr.(LeveledLogger); ok {
+		c.MinLevel = l.MinLogLevel()
+	}
+
+	d.mu.Lock()
+	c.SendTimeout = d.timeout
+	c.MaxFailures = d.maxFailures
+	c.Cooldown = d.cooldown
+	c.Groups = append([]string(nil), d.groups...)
+	c.Shadow = d.shadowOf
+	c.Overflow = d.backpressure.Overflow
+	c.HighWater = d.backpressure.HighWater
+	d.mu.Unlock()
+
+	return c
+}
+
+// ApplyConfig replaces the settings with c, typically a modified
+// CurrentConfig. All destinations of c must exist, destinations missing in c
+// keep their settings. Nothing is changed if c is invalid.
+func ApplyConfig(c Config) error {
+
+	var changes []Change
+	defer func() { auditChanges(changes...) }() // after configMu is released
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	// no destination is removed between validating and applying c
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	parallelDispatch := c.ParallelDispatch
+	if parallelDispatch < 0 {
+		parallelDispatch = 0
+	}
+	nilMsg := c.NilErrorMessage
+	if nilMsg == DefaultNilErrorMessage {
+		nilMsg = ""
+	}
+	currentSettings.Store(&settings{
+		reportCaller:     c.ReportCaller,
+		attachStacktrace: c.AttachStacktrace,
+		strictMode:       c.StrictMode,
+		nilErrorMessage:  nilMsg,
+		duplicateKeys:    c.DuplicateKeys,
+		contextNaming:    c.ContextNaming,
+		setupVerbosity:   c.SetupVerbosity,
+		crashDir:         c.CrashDir,
+		crashProfiles:    c.CrashProfiles,
+		crashCPUProfile:  c.CrashCPUProfile,
+		replay:           replayPolicy{maxAge: c.ReplayMaxAge, stale: c.ReplayStale},
+		parallelDispatch: parallelDispatch,
+		ordering:         c.Ordering,
+		priorityLanes:    c.PriorityLanes,
+		deadlineFields:   c.DeadlineFields,
+	})
+
+	fieldNamesMu.Lock()
+	aliases := make(map[string]string, len(c.FieldAliases))
+	for from, to := range c.FieldAliases {
+		aliases[from] = to
+	}
+	current := currentFieldNames()
+	fieldNaming.Store(&fieldNames{aliases: aliases, normalize: current.normalize, normalized: current.normalized})
+	fieldNamesMu.Unlock()
+
+	profilesMu.Lock()
+	profiles = make(map[string][]string, len(c.Profiles))
+	for name, groups := range c.Profiles {
+		profiles[name] = append([]string(nil), groups...)
+	}
+	profilesMu.Unlock()
+
+	for key, dc := range c.Destinations {
+		d, _ := lookup(key) // exists, validated under registryMu
+		changes = append(changes, d.apply(dc)...)
+	}
+
+	profilesMu.Lock()
+	from := activeProfile
+	activateLocked(c.ActiveProfile)
+	profilesMu.Unlock()
+
+	if ch, changed := recordChange(ChangedByConfig, "profile", "", from, c.ActiveProfile); changed {
+		changes = append(changes, ch)
+	}
+
+	return nil
+}
+
+func (c Config) validate() error {
+
+	if _, ok := c.Profiles[c.ActiveProfile]; !ok && c.ActiveProfile != "" {
+		return errors.New("config: routing profile doesn't exist: " + c.ActiveProfile)
+	}
+
+	for key, dc := range c.Destinations {
+		d, exists := lookup(key)
+		if !exists {
+			return errors.New("config: destination doesn't exist: " + key)
+		}
+		_, leveled := d.hub.Client().Transport.(LeveledLogger)
+		if !leveled && dc.MinLevel != 0 {
+			return fmt.Errorf("config: transport of destination %s has no level", key)
+		}
+		if leveled && (dc.MinLevel < DEBUG || dc.MinLevel > FATAL+1) {
+			return fmt.Errorf("config: MinLevel %d of destination %s is not a level", dc.MinLevel, key)
+		}
+		if dc.SampleRate <= 0 || dc.SampleRate > 1 {
+			return fmt.Errorf("config: SampleRate %v of destination %s is not in (0, 1]", dc.SampleRate, key)
+		}
+		if dc.Shadow != "" {
+			p, exists := c.Destinations[dc.Shadow]
+			if _, registered := lookup(dc.Shadow); !registered || dc.Shadow == key {
+				return fmt.Errorf("config: destination %s can't shadow %s", key, dc.Shadow)
+			}
+			if exists && p.Shadow != "" {
+				return fmt.Errorf("config: primary %s of destination %s is a shadow itself", dc.Shadow, key)
+			}
+		}
+	}
+	return nil
+}
+
+// applies c to the destination, returns the level and routing changes
+func (d *destination) apply(c DestinationConfig) []Change {
+
+	var changes []Change
+
+	if l, ok := d.hub.Client().Transport.(LeveledLogger); ok {
+		from := l.MinLogLevel()
+		l.SetLogLevel(c.MinLevel)
+		if ch, changed := recordChange(ChangedByConfig, "level", d.key, from.String(), c.MinLevel.String()); changed {
+			changes = append(changes, ch)
+		}
+	}
+	if c.AuditSink {
+		atomic.StoreInt32(&d.audit, 1)
+	} else {
+		atomic.StoreInt32(&d.audit, 0)
+	}
+	atomic.StoreInt32(&d.pii, int32(c.PIIPolicy))
+	d.setSampleRate(c.SampleRate)
+
+	d.mu.Lock()
+	d.timeout = c.SendTimeout
+	if d.maxFailures != c.MaxFailures || d.cooldown != c.Cooldown {
+		d.maxFailures, d.cooldown = c.MaxFailures, c.Cooldown
+		d.failures, d.openUntil = 0, time.Time{}
+	}
+	from := groupsString(d.groups)
+	d.groups = append([]string(nil), c.Groups...)
+	d.shadowOf = c.Shadow
+	d.backpressure.Overflow = c.Overflow
+	d.backpressure.HighWater = c.HighWater
+	d.mu.Unlock()
+
+	if ch, changed := recordChange(ChangedByConfig, "groups", d.key, from, groupsString(c.Groups)); changed {
+		changes = append(changes, ch)
+	}
+	return changes
+}
+
+// Diff lists the settings that differ from c in other, one line per setting:
+//
+//	Destinations.sentry.MinLevel: "INFO" -> "WARN"
+func (c Config) Diff(other Config) []string {
+
+	a, b := c.flatten(), other.flatten()
+
+	var diff []string
+	for k, v := range a {
+		if w, ok := b[k]; !ok {
+			diff = append(diff, fmt.Sprintf("%s: %s -> (none)", k, v))
+		} else if v != w {
+			diff = append(diff, fmt.Sprintf("%s: %s -> %s", k, v, w))
+		}
+	}
+	for k, w := range b {
+		if _, ok := a[k]; !ok {
+			diff = append(diff, fmt.Sprintf("%s: (none) -> %s", k, w))
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// settings by dotted path, values as JSON
+func (c Config) flatten() map[string]string {
+
+	flat := make(map[string]string)
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		diagnose(err)
+		return flat
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		diagnose(err)
+		return flat
+	}
+
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for k, e := range m {
+				walk(prefix+"."+k, e)
+			}
+			return
+		}
+		j, _ := json.Marshal(v)
+		flat[prefix[1:]] = string(j)
+	}
+	walk("", doc)
+
+	return flat
+}
@@ -0,0 +1,134 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func restoreConfig(t *testing.T) {
+
+	original := CurrentConfig()
+	t.Cleanup(func() {
+		if err := ApplyConfig(original); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestApplyConfigWhileRemovingDestination(t *testing.T) {
+
+	quiet(t)
+	restoreConfig(t)
+
+	for i := 0; i < 200; i++ {
+		if err := AddDestination("removed", sentry.ClientOptions{Transport: newRecordingTransport(DEBUG)}); err != nil {
+			t.Fatal(err)
+		}
+		c := CurrentConfig()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			RemoveDestination("removed")
+		}()
+		_ = ApplyConfig(c) // fails if the destination is gone, must not panic
+		<-done
+	}
+}
+
+func TestConfigIsAppliedAtOnce(t *testing.T) {
+
+	quiet(t)
+	restoreConfig(t)
+
+	a, b := CurrentConfig(), CurrentConfig()
+	a.Ordering, a.PriorityLanes = true, false
+	b.Ordering, b.PriorityLanes = false, true
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c := a
+			if i%2 == 1 {
+				c = b
+			}
+			if err := ApplyConfig(c); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if s := loadSettings(); s.ordering == s.priorityLanes {
+			t.Error("read half of an applied config")
+			break
+		}
+		if c := CurrentConfig(); c.Ordering == c.PriorityLanes {
+			t.Error("CurrentConfig returned half of an applied config")
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestCrashSettingsCanChangeConcurrently(t *testing.T) {
+
+	restoreConfig(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetCrashDir(t.TempDir())
+				SetCrashProfiles(j%2 == 0, 0)
+				_ = CurrentConfig().CrashDir
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestApplyConfigRejectsUnknownLevel(t *testing.T) {
+
+	quiet(t)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: newRecordingTransport(INFO)})
+
+	for _, level := range []Level{0, FATAL + 2} {
+		c := CurrentConfig()
+		dc := c.Destinations["rec"]
+		dc.MinLevel = level
+		c.Destinations["rec"] = dc
+		if err := ApplyConfig(c); err == nil {
+			t.Errorf("MinLevel %d applied, want an error", level)
+		}
+	}
+	if got := CurrentConfig().Destinations["rec"].MinLevel; got != INFO {
+		t.Errorf("MinLevel = %v, want %v", got, Level(INFO))
+	}
+}
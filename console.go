@@ -0,0 +1,52 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// NoConsoleEnv names the environment variable which, set to any non-empty
+// value, keeps senlog from adding the default console destination. Building
+// with the senlog_noconsole tag does the same.
+const NoConsoleEnv = "SENLOG_NO_CONSOLE"
+
+var consoleOnce sync.Once
+
+// adds the default console destination, once on first use of the registry
+// instead of on import, so importing senlog has no side effects. Every reader
+// and writer of the registry goes through destinations(), which waits for it,
+// so the registry is stored without registryMu, which a caller may hold.
+func addConsole() {
+
+	if !defaultConsole || os.Getenv(NoConsoleEnv) != "" {
+		return
+	}
+
+	options := sentry.ClientOptions{
+		Dsn:       "",
+		Transport: defaultConsoleTransport(),
+	}
+
+	d, err := newDestination("console", options)
+	if err != nil {
+		diagnose(fmt.Errorf("could not initiate log destination console: %w", err))
+		return
+	}
+
+	d.debugSampling = 1 // see SetDebugSampling
+	registry.Store([]*destination{d})
+	d.added(options)
+}
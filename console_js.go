@@ -0,0 +1,83 @@
+//go:build js && wasm
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// BrowserConsoleTransport logs events to the console of the browser or of
+// Node.js: DEBUG to console.debug, INFO to console.info, WARN to console.warn,
+// ERROR and FATAL to console.error. The message is followed by an object
+// holding the fields, error and stacktrace, which the devtools can expand.
+// It is the default console destination of js/wasm builds.
+type BrowserConsoleTransport struct {
+	Logger
+}
+
+func NewBrowserConsoleTransport(minLogLevel Level) *BrowserConsoleTransport {
+
+	t := new(BrowserConsoleTransport)
+	t.SetLogLevel(minLogLevel)
+	return t
+}
+
+var consoleMethods = map[sentry.Level]string{
+	sentry.LevelDebug:   "debug",
+	sentry.LevelInfo:    "info",
+	sentry.LevelWarning: "warn",
+	sentry.LevelError:   "error",
+	sentry.LevelFatal:   "error",
+}
+
+func (t *BrowserConsoleTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *BrowserConsoleTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		console := js.Global().Get("console")
+		if console.IsUndefined() {
+			return
+		}
+
+		method, ok := consoleMethods[ev.Level]
+		if !ok {
+			method = "log"
+		}
+
+		obj := jsonObject(ev)
+		delete(obj, "msg") // the first argument
+		b, err := json.Marshal(obj)
+		if err != nil {
+			diagnose(fmt.Errorf("could not encode event for the browser console: %w", err))
+			console.Call(method, ev.Message)
+			return
+		}
+
+		console.Call(method, ev.Message, js.Global().Get("JSON").Call("parse", string(b)))
+	}, ev)
+}
+
+func (t *BrowserConsoleTransport) Flush(time.Duration) bool {
+	return true
+}
+
+func defaultConsoleTransport() sentry.Transport {
+	return NewBrowserConsoleTransport(DEBUG)
+}
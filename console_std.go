@@ -0,0 +1,42 @@
+//go:build !(js && wasm) && !android && !(ios && cgo)
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"os"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// colored text lines, or on serverless platforms the structured JSON their log
+// viewers expect, without colors and time header
+func defaultConsoleTransport() sentry.Transport {
+
+	if f, ok := platformFormat(); ok {
+		return NewTransport(os.Stdout, WithErrWriter(os.Stderr), WithColors(nil), WithFormat(f))
+	}
+	return NewIoTransport(os.Stdout, os.Stderr, DEBUG)
+}
+
+// log format of the serverless platform the program runs on, detected by the
+// environment variables the platform sets
+func platformFormat() (Format, bool) {
+
+	switch {
+	case os.Getenv("K_SERVICE") != "": // Cloud Run, also Knative
+		return CloudLoggingFormat, true
+	case os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "":
+		return LambdaFormat, true
+	}
+	return TextFormat, false
+}
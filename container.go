@@ -0,0 +1,115 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// DockerLineSize is the size of log lines, newline included, above which
+// Docker splits them. A JSON document of an event which doesn't fit is written
+// as chunk lines, each a JSON object holding a part of the document in order:
+//
+//	{"senlog.chunk":"<event id>","index":1,"count":3,"data":"{\"time\":..."}
+//
+// The document is the concatenation of the data of all chunks of an event.
+// Longer text lines are split, continued lines start with "+ ".
+const DockerLineSize = 16 * 1024
+
+// space of a chunk line taken by everything but the data
+const chunkOverhead = 128
+
+type chunk struct {
+	EventID string `json:"senlog.chunk"`
+	Index   int    `json:"index"`
+	Count   int    `json:"count"`
+	Data    string `json:"data"`
+}
+
+// b with lines longer than max bytes split, as chunk lines for documents
+func splitLines(ev *sentry.Event, b []byte, max int, document bool) []byte {
+
+	if document {
+		if len(b) <= max {
+			return b
+		}
+		return chunkDocument(ev, b, max)
+	}
+
+	if max < 8 { // no room to split
+		return b
+	}
+
+	var out []byte
+	for i, line := range bytes.Split(b, []byte("\n")) {
+		if i > 0 {
+			out = append(out, '\n')
+		}
+		limit := max
+		for len(line) > limit {
+			n := cut(line, limit)
+			out = append(out, line[:n]...)
+			out = append(out, "\n+ "...)
+			line = line[n:]
+			limit = max - 2 // room for the continuation marker
+		}
+		out = append(out, line...)
+	}
+	return out
+}
+
+// document as chunk lines of at most max bytes
+func chunkDocument(ev *sentry.Event, doc []byte, max int) []byte {
+
+	size := (max - chunkOverhead) / 2 // escaping can double the data
+	if size < 1 {
+		return doc
+	}
+
+	var parts [][]byte
+	for len(doc) > 0 {
+		n := cut(doc, size)
+		parts = append(parts, doc[:n])
+		doc = doc[n:]
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	for i, part := range parts {
+		c := chunk{EventID: string(ev.EventID), Index: i + 1, Count: len(parts), Data: string(part)}
+		if err := enc.Encode(c); err != nil {
+			diagnose(err)
+			return nil
+		}
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n"))
+}
+
+// length of the longest prefix of b up to max bytes not splitting a UTF-8 rune
+func cut(b []byte, max int) int {
+
+	if len(b) <= max {
+		return len(b)
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(b[n]) {
+		n--
+	}
+	if n == 0 {
+		return max
+	}
+	return n
+}
@@ -0,0 +1,115 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestContextSetLeavesItsBaseUnchanged(t *testing.T) {
+
+	base := Cxt("req").Set("id", 1)
+	first := base.Set("step", "first")
+	second := base.Set("step", "second")
+	third := first.Set("more", true)
+
+	group := func(x *Context) map[string]interface{} {
+		return x.contexts()["req"].(map[string]interface{})
+	}
+
+	if g := group(base); len(g) != 1 {
+		t.Errorf("base = %v, want only id", g)
+	}
+	if g := group(first); g["step"] != "first" || len(g) != 2 {
+		t.Errorf("first = %v", g)
+	}
+	if g := group(second); g["step"] != "second" || len(g) != 2 {
+		t.Errorf("second = %v", g)
+	}
+	if g := group(third); g["step"] != "first" || g["more"] != true {
+		t.Errorf("third = %v", g)
+	}
+}
+
+func TestContextSharedAcrossGoroutines(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	base := Cxt("req").Set("id", 1)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			x := base
+			for j := 0; j < 10; j++ {
+				x = x.Set(fmt.Sprint("k", j), i)
+			}
+			x.INF("shared")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, ev := range rec.Events() {
+		if ev.Message != "shared" {
+			continue
+		}
+		g := ev.Contexts["req"].(map[string]interface{})
+		if len(g) != 11 || g["k0"] != g["k9"] {
+			t.Errorf("event got fields of another goroutine: %v", g)
+		}
+	}
+}
+
+func TestContextChainedSetIsLinear(t *testing.T) {
+
+	keys := make([]string, 10000)
+	for i := range keys {
+		keys[i] = fmt.Sprint("k", i)
+	}
+
+	chain := func(n int) uint64 {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		x := Cxt("big")
+		for i := 0; i < n; i++ {
+			x = x.Set(keys[i], i)
+		}
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+
+	small, large := chain(1000), chain(10000)
+	if large > 20*small {
+		t.Errorf("10x the fields allocate %d bytes, %dx those of 1000 fields", large, large/small)
+	}
+}
+
+func TestContextReopenStartsAnew(t *testing.T) {
+
+	x := Cxt("req").Set("id", 1).Cxt("db").Set("table", "users").Cxt("req").Set("step", 2)
+
+	contexts := x.contexts()
+	if g := contexts["req"].(map[string]interface{}); len(g) != 1 || g["step"] != 2 {
+		t.Errorf("reopened req = %v, want only step", g)
+	}
+	if g := contexts["db"].(map[string]interface{}); g["table"] != "users" {
+		t.Errorf("db = %v", g)
+	}
+}
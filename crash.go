@@ -0,0 +1,186 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const crashFilePrefix = "senlog-crash-"
+
+// CrashReport is the content of a crash marker file written by FTL
+type CrashReport struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	Message     string        `json:"message"`
+	Error       string        `json:"error,omitempty"`
+	Fingerprint string        `json:"fingerprint"`
+	EventID     string        `json:"event_id"`
+	Event       *sentry.Event `json:"event"`              // the fatal event as sent to destinations
+	Profiles    []string      `json:"profiles,omitempty"` // pprof files, see SetCrashProfiles
+}
+
+// SetCrashDir makes FTL write a crash marker file to dir before exiting, so the
+// next program start can detect and report the crash even if the event never
+// reached Sentry. An empty dir disables crash markers.
+func SetCrashDir(dir string) {
+	updateSettings(func(s *settings) { s.crashDir = dir })
+}
+
+// ReportPreviousCrash re-submits the events of crash markers left in the crash
+// directory by a previous run and removes the markers, then replays the outboxes
+// of Sentry destinations. Call it on startup after adding destinations. Sentry
+// drops a re-submitted event if it already received the original, as both share
+// the same event ID.
+func ReportPreviousCrash() (reported int, err error) {
+
+	for _, d := range destinations() {
+		if tr, ok := d.hub.Client().Transport.(*SentryTransport); ok {
+			n, e := tr.ReplayOutbox()
+			reported += n
+			if e != nil {
+				err = e
+			}
+		}
+	}
+
+	crashDir := loadSettings().crashDir
+	if crashDir == "" {
+		return reported, err
+	}
+
+	files, e := filepath.Glob(filepath.Join(crashDir, crashFilePrefix+"*.json"))
+	if e != nil {
+		return reported, e
+	}
+
+	for _, file := range files {
+
+		b, e := os.ReadFile(file)
+		if e != nil {
+			err = e
+			continue
+		}
+
+		var report CrashReport
+		if e := json.Unmarshal(b, &report); e != nil || report.Event == nil {
+			Set("file", file).WRN("Ignoring unreadable crash marker")
+			os.Remove(file)
+			continue
+		}
+
+		ev := report.Event
+		if ev.Tags == nil {
+			ev.Tags = make(map[string]string)
+		}
+		ev.Tags["senlog.replay"] = "crash"
+		if len(report.Profiles) > 0 {
+			if ev.Extra == nil {
+				ev.Extra = make(map[string]interface{})
+			}
+			ev.Extra["profiles"] = report.Profiles
+		}
+
+		if replayable(ev) { // not too old, see SetReplayMaxAge
+			broadcast(context.Background(), nil, sentryRecord(ev))
+			reported++
+
+			Set("event_id", report.EventID).Set("crashed_at", report.Timestamp).INF("Reported crash of previous run")
+		}
+
+		if e := os.Remove(file); e != nil {
+			err = e
+		}
+	}
+
+	return reported, err
+}
+
+// log the fatal record to disk, flush destinations and exit
+func fatal(r *Record) {
+
+	if dir := loadSettings().crashDir; r != nil && dir != "" {
+		if err := writeCrashMarker(dir, r.event()); err != nil {
+			diagnose(fmt.Errorf("could not write crash marker: %w", err))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+	endSession(ctx, "crashed")
+	cancel()
+	flush(FlushTimeout)
+	os.Exit(1)
+}
+
+func writeCrashMarker(dir string, ev *sentry.Event) error {
+
+	ev = applyPIIPolicy(ev, PIIHash) // no raw personal data on disk
+
+	report := CrashReport{
+		Timestamp:   ev.Timestamp,
+		Message:     ev.Message,
+		Fingerprint: fingerprint(ev),
+		EventID:     string(ev.EventID),
+		Event:       ev,
+	}
+	if loadSettings().crashProfiles {
+		report.Profiles = writeCrashProfiles(dir, ev.Timestamp)
+	}
+	if len(ev.Exception) > 0 {
+		report.Error = ev.Exception[len(ev.Exception)-1].Value
+	}
+
+	b, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(dir, fmt.Sprintf("%s%d.json", crashFilePrefix, ev.Timestamp.UnixNano()))
+	return os.WriteFile(name, b, 0644)
+}
+
+// stable id of a crash cause: event fingerprint if set, otherwise hash of error types and message
+func fingerprint(ev *sentry.Event) string {
+
+	if len(ev.Fingerprint) > 0 {
+		return strings.Join(ev.Fingerprint, ",")
+	}
+
+	h := sha256.New()
+	for _, ex := range ev.Exception {
+		h.Write([]byte(ex.Type))
+		h.Write([]byte{0})
+	}
+	h.Write([]byte(ev.Message))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// random uuid4 in the format sentry expects, 32 lowercase hex chars without dashes
+func newEventID() sentry.EventID {
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "" // sentry generates one
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant RFC 4122
+	return sentry.EventID(hex.EncodeToString(b[:]))
+}
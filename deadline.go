@@ -0,0 +1,67 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"time"
+)
+
+// SetDeadlineFields sets whether WARN, ERROR and FATAL events logged with the
+// Ctx variants get a "deadline" context describing ctx, on by default: the time
+// remaining until its deadline, negative once exceeded, whether it is canceled
+// and why. Contexts that can't be canceled add nothing. These events are sent
+// even if ctx is already done, rather than dropped like other Ctx events.
+func SetDeadlineFields(on bool) {
+	updateSettings(func(s *settings) { s.deadlineFields = on })
+}
+
+// adds the deadline context of ctx to r, returns the context to send r with
+func applyDeadline(ctx context.Context, r *Record) context.Context {
+
+	if !loadSettings().deadlineFields || r.Level < WARN {
+		return ctx
+	}
+	if ctx == nil || ctx.Done() == nil { // can't be canceled, e.g. context.Background()
+		return ctx
+	}
+
+	fields := make(map[string]interface{}, 3)
+	if deadline, ok := ctx.Deadline(); ok {
+		fields["remaining"] = time.Until(deadline).Round(time.Millisecond).String()
+	}
+	err := ctx.Err()
+	fields["canceled"] = err != nil
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	if r.Contexts == nil {
+		r.Contexts = make(map[string]map[string]interface{})
+	}
+	if _, exists := r.Contexts["deadline"]; exists { // set by the log call
+		return ctx
+	}
+	r.Contexts["deadline"] = fields
+
+	if err != nil { // the event is about the done ctx, don't drop it for that
+		return detachedContext{ctx}
+	}
+	return ctx
+}
+
+// values of the context without its cancellation, like context.WithoutCancel
+// of Go 1.21
+type detachedContext struct{ context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
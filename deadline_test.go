@@ -0,0 +1,102 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// deadline context of the event with msg, nil if it has none
+func deadlineOf(t *testing.T, rec *recordingTransport, msg string) map[string]interface{} {
+
+	t.Helper()
+	for _, ev := range rec.Events() {
+		if ev.Message == msg {
+			fields, _ := ev.Contexts["deadline"].(map[string]interface{})
+			return fields
+		}
+	}
+	t.Fatalf("%q not sent, got %q", msg, rec.Messages())
+	return nil
+}
+
+func TestDeadlineFieldsOfWarnings(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	WRNCtx(ctx, "slow")
+	INFCtx(ctx, "info")
+	WRNCtx(context.Background(), "background")
+
+	fields := deadlineOf(t, rec, "slow")
+	remaining, _ := time.ParseDuration(fmt.Sprint(fields["remaining"]))
+	if fields["canceled"] != false || remaining < 59*time.Second || remaining > time.Minute {
+		t.Errorf("deadline of WARN is %v, want about a minute remaining", fields)
+	}
+	if fields := deadlineOf(t, rec, "info"); fields != nil {
+		t.Errorf("INFO got deadline %v", fields)
+	}
+	if fields := deadlineOf(t, rec, "background"); fields != nil {
+		t.Errorf("context without deadline got %v", fields)
+	}
+}
+
+func TestDeadlineFieldsOfCanceledContext(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ERRCtx(ctx, errors.New("gave up"), "canceled") // sent although ctx is done
+	INFCtx(ctx, "dropped")
+
+	fields := deadlineOf(t, rec, "canceled")
+	if fields["canceled"] != true || fields["error"] != context.Canceled.Error() {
+		t.Errorf("deadline is %v, want canceled", fields)
+	}
+	if _, has := fields["remaining"]; has {
+		t.Errorf("context without deadline has remaining time: %v", fields)
+	}
+	if contains(rec.Messages(), "dropped") {
+		t.Error("INFO of a canceled context sent")
+	}
+}
+
+func TestDeadlineFieldsOff(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	SetDeadlineFields(false)
+	defer SetDeadlineFields(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	WRNCtx(ctx, "slow")
+
+	if fields := deadlineOf(t, rec, "slow"); fields != nil {
+		t.Errorf("deadline %v with deadline fields off", fields)
+	}
+}
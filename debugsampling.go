@@ -0,0 +1,122 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// DebugSampledHeader carries the debug sampling decision of a request to the
+// services it calls, as HTTP header or gRPC metadata key
+const DebugSampledHeader = "X-Senlog-Debug-Sampled"
+
+// tag of events logged for a debug sampled request
+const debugSampledTag = "senlog.debug_sampled"
+
+type debugSampledKey struct{}
+
+// SampleDebug marks a share rate (0 to 1) of requests as debug sampled, at the
+// edge of the system. Events logged with the Ctx variants for a debug sampled
+// ctx, e.g. DBGCtx(ctx, ...), are tagged senlog.debug_sampled and written by
+// the default console and the destinations opted in by SetDebugSampling,
+// whatever their level. A ctx already sampled is kept.
+func SampleDebug(ctx context.Context, rate float64) context.Context {
+
+	if debugSampled(ctx) || rate <= 0 || rand.Float64() >= rate {
+		return ctx
+	}
+	return WithDebugSampled(ctx)
+}
+
+// SetDebugSampling lets the events of debug sampled requests bypass the level
+// of a destination, see SampleDebug. Only the default console destination
+// does by default, so debug sampling doesn't flood e.g. a Sentry quota.
+func SetDebugSampling(destinationKey string, on bool) {
+
+	d, exists := lookup(destinationKey)
+	if !exists { // destination doesn't exist
+		notice("cannot set debug sampling, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	if on {
+		atomic.StoreInt32(&d.debugSampling, 1)
+	} else {
+		atomic.StoreInt32(&d.debugSampling, 0)
+	}
+}
+
+// WithDebugSampled returns a copy of ctx marked as debug sampled, see SampleDebug
+func WithDebugSampled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugSampledKey{}, true)
+}
+
+func debugSampled(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	sampled, _ := ctx.Value(debugSampledKey{}).(bool)
+	return sampled
+}
+
+// InjectDebugSampled adds the debug sampling decision of ctx to the headers of
+// an outgoing HTTP request:
+//
+//	senlog.InjectDebugSampled(ctx, req.Header)
+func InjectDebugSampled(ctx context.Context, header http.Header) {
+
+	if debugSampled(ctx) {
+		header.Set(DebugSampledHeader, "1")
+	}
+}
+
+// InjectDebugSampledMD adds the debug sampling decision of ctx to gRPC metadata,
+// which wants lower case keys:
+//
+//	md, _ := metadata.FromOutgoingContext(ctx)
+//	md = md.Copy()
+//	senlog.InjectDebugSampledMD(ctx, md)
+//	ctx = metadata.NewOutgoingContext(ctx, md)
+func InjectDebugSampledMD(ctx context.Context, md map[string][]string) {
+
+	if debugSampled(ctx) {
+		md[strings.ToLower(DebugSampledHeader)] = []string{"1"}
+	}
+}
+
+// ExtractDebugSampled returns ctx marked as debug sampled if the headers of an
+// incoming request, an http.Header or gRPC metadata.MD, carry the decision
+func ExtractDebugSampled(ctx context.Context, carrier map[string][]string) context.Context {
+
+	v, ok := carrier[http.CanonicalHeaderKey(DebugSampledHeader)]
+	if !ok {
+		v = carrier[strings.ToLower(DebugSampledHeader)]
+	}
+	if len(v) > 0 && v[0] == "1" {
+		return WithDebugSampled(ctx)
+	}
+	return ctx
+}
+
+// DebugSampledHandler honors the debug sampling decision of the calling
+// service for requests to next, and samples a share rate of the other requests
+func DebugSampledHandler(rate float64, next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		ctx := SampleDebug(ExtractDebugSampled(r.Context(), r.Header), rate)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,65 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestDebugSamplingBypassesOnlyOptedInDestinations(t *testing.T) {
+
+	if !debugBuilt {
+		t.Skip("DEBUG logging compiled out by senlog_nodebug")
+	}
+	quiet(t)
+	rec := newRecordingTransport(ERROR)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	ctx := WithDebugSampled(context.Background())
+
+	DBGCtx(ctx, "not opted in")
+	SetDebugSampling("rec", true)
+	DBGCtx(ctx, "opted in")
+
+	msgs := rec.Messages()
+	if contains(msgs, "not opted in") {
+		t.Error("destination not opted in got a debug sampled event below its level")
+	}
+	if !contains(msgs, "opted in") {
+		t.Errorf("opted in destination got %q, want the debug sampled event", msgs)
+	}
+}
+
+func TestDebugSampledHeaderIsCanonical(t *testing.T) {
+
+	ctx := WithDebugSampled(context.Background())
+
+	header := make(http.Header)
+	InjectDebugSampled(ctx, header)
+	if header.Get(DebugSampledHeader) != "1" {
+		t.Errorf("header = %v, want the canonical key", header)
+	}
+	if !debugSampled(ExtractDebugSampled(context.Background(), header)) {
+		t.Error("decision not extracted from http.Header")
+	}
+
+	md := make(map[string][]string)
+	InjectDebugSampledMD(ctx, md)
+	if _, ok := md["x-senlog-debug-sampled"]; !ok {
+		t.Errorf("metadata = %v, want the lower case key", md)
+	}
+	if !debugSampled(ExtractDebugSampled(context.Background(), md)) {
+		t.Error("decision not extracted from metadata")
+	}
+}
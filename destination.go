@@ -0,0 +1,438 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// log destination, a sentry hub with its own client and transport
+type destination struct {
+	key            string
+	hub            *sentry.Hub
+	sink           *SinkTransport // sent to without the hub, see AddSink
+	sent           uint64         // events handed to the transport
+	filtered       uint64         // events below the transport's level
+	dropped        uint64         // events given up before delivery, e.g. on context cancellation
+	fails          uint64         // sends that timed out or failed in the transport, also counted as dropped
+	audit          int32          // 1 for audit sinks, see Audit()
+	aboveHighWater int32          // 1 while the queue is above its high-water mark
+	pii            int32          // PIIPolicy
+	inactive       int32          // 1 if the active routing profile excludes the destination
+	reports        int32          // 1 if the transport reports its send results, see watchResults
+	debugSampling  int32          // 1 if events of debug sampled requests bypass the level, see SetDebugSampling
+	sampleRate     uint64         // float64 bits of the share of events sent, see SetSampling
+
+	mu           sync.Mutex
+	timeout      time.Duration // max time to wait for a send, 0 waits forever
+	maxFailures  int           // consecutive failures tripping the breaker, 0 disables it
+	cooldown     time.Duration // how long a tripped breaker skips sends
+	failures     int
+	openUntil    time.Time
+	groups       []string // routing groups, see SetDestinationGroups
+	dry          dryClient
+	shadowOf     string                   // key of the primary destination, see SetShadow
+	events       chan *Record             // queue of parallel dispatch, see SetParallelDispatch
+	urgent       chan *Record             // priority lane of the queue, see SetPriorityLanes
+	slots        chan struct{}            // sends of ordered parallel dispatch
+	sequences    map[string]chan struct{} // last turn by sequence, see SetOrdering
+	backpressure Backpressure
+
+	stop chan struct{} // closed by RemoveDestination, ends the queue's goroutine
+}
+
+// destinations registry, the slice stored is never modified, writers replace it
+// under registryMu so capture can read it without locking
+var (
+	registryMu sync.Mutex
+	registry   atomic.Value // []*destination
+)
+
+// destinations, the default console destination is added on first use
+func destinations() []*destination {
+	consoleOnce.Do(addConsole)
+	ds, _ := registry.Load().([]*destination)
+	return ds
+}
+
+func lookup(key string) (*destination, bool) {
+
+	for _, d := range destinations() {
+		if d.key == key {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// binds a new client with options to the destination's hub. The events queued
+// by the current client are flushed first, a new client reconfigures the
+// transport, which may drop its queue, e.g. sentry.HTTPTransport.
+func (d *destination) rebind(options sentry.ClientOptions) error {
+
+	if !d.hub.Client().Flush(FlushTimeout) {
+		diagnose(fmt.Errorf("destination %s: events queued before its client changed may be lost", d.key))
+	}
+
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		return err
+	}
+	d.hub.BindClient(client)
+	d.watchResults()
+	return nil
+}
+
+// transports reporting the outcome of their requests, e.g. SentryTransport
+type resultReporter interface {
+	reportResults(fn func(error))
+}
+
+// has the transport of the bound client report its results to the circuit
+// breaker, the breaker then doesn't take a returning send as success
+func (d *destination) watchResults() {
+
+	r, ok := d.hub.Client().Transport.(resultReporter)
+	if !ok {
+		atomic.StoreInt32(&d.reports, 0)
+		return
+	}
+	r.reportResults(d.sendResult)
+	atomic.StoreInt32(&d.reports, 1)
+}
+
+// outcome of a request of the transport, an error fails the send of its event
+func (d *destination) sendResult(err error) {
+
+	if err == nil {
+		d.succeeded()
+		return
+	}
+	atomic.AddUint64(&d.dropped, 1)
+	d.failed()
+}
+
+// sends still running in the background, e.g. after a timeout
+var pending sync.WaitGroup
+
+// waits at most timeout for the sends running in the background, false if
+// some still run, e.g. against a hung backend
+func waitPending(timeout time.Duration) bool {
+
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// set max time to wait for a destination to send an event
+func SetSendTimeout(destinationKey string, timeout time.Duration) {
+
+	d, exists := lookup(destinationKey)
+	if !exists { // destination doesn't exist
+		notice("cannot set send timeout, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	d.mu.Lock()
+	d.timeout = timeout
+	d.mu.Unlock()
+}
+
+// skip sends to a destination for cooldown after maxFailures consecutive failed
+// sends, skipped events are counted as dropped. Sends fail by timing out, see
+// SetSendTimeout, and for transports reporting their requests, like
+// SentryTransport, by a request error or an error response. A 429 response
+// doesn't count, Sentry's rate limit pauses sends by itself.
+func SetCircuitBreaker(destinationKey string, maxFailures int, cooldown time.Duration) {
+
+	d, exists := lookup(destinationKey)
+	if !exists { // destination doesn't exist
+		notice("cannot set circuit breaker, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	d.mu.Lock()
+	d.maxFailures = maxFailures
+	d.cooldown = cooldown
+	d.failures = 0
+	d.openUntil = time.Time{}
+	d.mu.Unlock()
+}
+
+func (d *destination) send(ctx context.Context, r *Record) {
+	d.sendTurn(ctx, r, turn{})
+}
+
+// sends r after the events before it in the sequence of t, see SetOrdering
+func (d *destination) sendTurn(ctx context.Context, r *Record, t turn) {
+
+	if dryRunReport() != nil {
+		t.wait()
+		d.dryRun(r)
+		d.finish(t)
+		return
+	}
+
+	d.mu.Lock()
+	timeout := d.timeout
+	open := time.Now().Before(d.openUntil)
+	d.mu.Unlock()
+
+	if open { // circuit breaker tripped, backend is considered down
+		d.drop(r, DroppedByBreaker)
+		d.skip(t)
+		return
+	}
+
+	if timeout == 0 && ctx.Done() == nil { // can't time out, send synchronously
+		t.wait()
+		d.capture(r)
+		d.finish(t)
+		atomic.AddUint64(&d.sent, 1)
+		return
+	}
+
+	if ctx.Err() != nil {
+		d.drop(r, DroppedByContext)
+		d.skip(t)
+		return
+	}
+
+	sendCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		t.wait()
+		d.capture(r)
+		d.finish(t)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		atomic.AddUint64(&d.sent, 1)
+		if atomic.LoadInt32(&d.reports) == 0 { // else reported by the transport
+			d.succeeded()
+		}
+	case <-sendCtx.Done():
+		if ctx.Err() == nil { // our own timeout, not the caller giving up
+			if atomic.LoadInt32(&d.reports) == 1 { // the transport reports the outcome when the request ends
+				return
+			}
+			d.drop(r, DroppedByTimeout)
+			d.failed()
+		} else {
+			d.drop(r, DroppedByContext)
+		}
+	}
+}
+
+func (d *destination) succeeded() {
+
+	d.mu.Lock()
+	d.failures = 0
+	d.mu.Unlock()
+}
+
+func (d *destination) failed() {
+
+	atomic.AddUint64(&d.fails, 1)
+
+	d.mu.Lock()
+	d.failures++
+	tripped := d.maxFailures > 0 && d.failures >= d.maxFailures
+	if tripped {
+		d.failures = 0
+		d.openUntil = time.Now().Add(d.cooldown)
+	}
+	cooldown := d.cooldown
+	d.mu.Unlock()
+
+	if tripped {
+		Set("destination", d.key).Set("cooldown", cooldown.String()).Except(d.key).WRN("Circuit breaker tripped, skipping sends to log destination")
+	}
+}
+
+// whether the destination's transport logs the level of r. Records of debug
+// sampled requests pass only destinations opted in by SetDebugSampling.
+func (d *destination) accepts(r *Record) bool {
+
+	l, ok := d.hub.Client().Transport.(LeveledLogger)
+	if !ok || r.Level >= l.MinLogLevel() || r.Logger == auditLoggerName {
+		return true
+	}
+	return r.Tags[debugSampledTag] == "true" && atomic.LoadInt32(&d.debugSampling) == 1
+}
+
+// flush all destinations, each waits at most timeout
+func flush(timeout time.Duration) {
+
+	drainQueues(timeout)
+	for _, d := range destinations() {
+		d.hub.Flush(timeout)
+	}
+}
+
+// shallow copy of event with its own top level maps
+func copyEvent(ev *sentry.Event) *sentry.Event {
+
+	c := *ev
+	c.Contexts = make(map[string]interface{}, len(ev.Contexts))
+	for k, v := range ev.Contexts {
+		c.Contexts[k] = v
+	}
+	c.Tags = make(map[string]string, len(ev.Tags))
+	for k, v := range ev.Tags {
+		c.Tags[k] = v
+	}
+	c.Extra = make(map[string]interface{}, len(ev.Extra))
+	for k, v := range ev.Extra {
+		c.Extra[k] = v
+	}
+	return &c
+}
+
+// the destination's own copy of r with its PII policy applied, every record
+// reaches the transport or sink through it
+func (d *destination) own(r *Record) *Record {
+
+	c := cloneRecord(r)
+	resolvePII(c, d.piiPolicy())
+	return c
+}
+
+// deep copy of event, e.g. of the sentry-only parts of a record, see event.
+// Maps and slices of field values are copied, other values, e.g. pointers, are
+// shared.
+func cloneEvent(ev *sentry.Event) *sentry.Event {
+
+	c := copyEvent(ev)
+	for k, v := range c.Contexts {
+		c.Contexts[k] = cloneValue(v)
+	}
+	for k, v := range c.Extra {
+		c.Extra[k] = cloneValue(v)
+	}
+
+	c.Fingerprint = append([]string(nil), ev.Fingerprint...)
+
+	if ev.Breadcrumbs != nil {
+		c.Breadcrumbs = make([]*sentry.Breadcrumb, len(ev.Breadcrumbs))
+		for i, b := range ev.Breadcrumbs {
+			if b != nil {
+				cb := *b
+				cb.Data, _ = cloneValue(b.Data).(map[string]interface{})
+				b = &cb
+			}
+			c.Breadcrumbs[i] = b
+		}
+	}
+
+	if ev.Exception != nil {
+		c.Exception = make([]sentry.Exception, len(ev.Exception))
+		for i, ex := range ev.Exception {
+			ex.Stacktrace = cloneStacktrace(ex.Stacktrace)
+			c.Exception[i] = ex
+		}
+	}
+	if ev.Threads != nil {
+		c.Threads = make([]sentry.Thread, len(ev.Threads))
+		for i, th := range ev.Threads {
+			th.Stacktrace = cloneStacktrace(th.Stacktrace)
+			c.Threads[i] = th
+		}
+	}
+
+	if ev.Request != nil {
+		r := *ev.Request
+		r.Headers = cloneStrings(ev.Request.Headers)
+		r.Env = cloneStrings(ev.Request.Env)
+		c.Request = &r
+	}
+	c.Modules = cloneStrings(ev.Modules)
+
+	return c
+}
+
+// copy of maps and slices in v, recursively
+func cloneValue(v interface{}) interface{} {
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = cloneValue(e)
+		}
+		return c
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = cloneValue(e)
+		}
+		return c
+	case map[string]string:
+		return cloneStrings(v)
+	case []string:
+		return append([]string(nil), v...)
+	}
+	return v
+}
+
+func cloneStrings(m map[string]string) map[string]string {
+
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneStacktrace(st *sentry.Stacktrace) *sentry.Stacktrace {
+
+	if st == nil {
+		return nil
+	}
+	c := *st
+	c.Frames = append([]sentry.Frame(nil), st.Frames...)
+	c.FramesOmitted = append([]uint(nil), st.FramesOmitted...)
+	return &c
+}
@@ -0,0 +1,75 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestBeforeSendDoesntChangeOtherDestinations(t *testing.T) {
+
+	quiet(t)
+	changed := newRecordingTransport(DEBUG)
+	addTestDestination(t, "changing", sentry.ClientOptions{Transport: changed, BeforeSend: func(ev *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		if ev.Message != "Order failed" { // setup notice
+			return ev
+		}
+		ev.Contexts["order"].(map[string]interface{})["id"] = "changed"
+		ev.Contexts["order"].(map[string]interface{})["items"].([]interface{})[0] = "changed"
+		ev.Tags["tag"] = "changed"
+		ev.Exception[0].Value = "changed"
+		if st := ev.Exception[0].Stacktrace; st != nil && len(st.Frames) > 0 {
+			st.Frames[0].Function = "changed"
+		}
+		return ev
+	}})
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	Cxt("order").Set("id", 7).Set("items", []interface{}{"book"}).ERR(errors.New("failed"), "Order failed")
+
+	var got, other *sentry.Event
+	for _, ev := range changed.Events() {
+		if ev.Message == "Order failed" {
+			got = ev
+		}
+	}
+	for _, ev := range rec.Events() {
+		if ev.Message == "Order failed" {
+			other = ev
+		}
+	}
+	if got == nil || other == nil {
+		t.Fatal("event not sent to both destinations")
+	}
+	if id, _ := field(got, "order", "id"); id != "changed" {
+		t.Fatalf("BeforeSend didn't run, id %v", id)
+	}
+
+	if id, _ := field(other, "order", "id"); id != 7 {
+		t.Errorf("id %v, want 7", id)
+	}
+	if items, _ := field(other, "order", "items"); items.([]interface{})[0] != "book" {
+		t.Errorf("items %v, want book", items)
+	}
+	if other.Tags["tag"] == "changed" {
+		t.Error("tag changed")
+	}
+	if other.Exception[0].Value != "failed" {
+		t.Errorf("exception %q, want failed", other.Exception[0].Value)
+	}
+	if st := other.Exception[0].Stacktrace; st != nil && len(st.Frames) > 0 && st.Frames[0].Function == "changed" {
+		t.Error("stack frame changed")
+	}
+}
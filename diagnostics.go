@@ -0,0 +1,50 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+var (
+	diagnostics atomic.Value // func(error)
+	diagnosing  int32        // 1 while the diagnostics handler runs
+)
+
+// SetDiagnostics sets the handler of internal senlog failures, e.g. events that
+// can not be encoded, failed writes or files that can not be opened, and of
+// throttled configuration notices like a missing destination, and of filter
+// reports, see SetFilterReport.
+// By default they are printed to stderr, nil restores the default.
+// Failures raised while the handler runs are printed to stderr, so a handler
+// logging through senlog can not recurse.
+func SetDiagnostics(fn func(err error)) {
+	diagnostics.Store(fn)
+}
+
+// report an internal failure, never through the logging api itself
+func diagnose(err error) {
+
+	if err == nil {
+		return
+	}
+
+	fn, _ := diagnostics.Load().(func(error))
+	if fn == nil || !atomic.CompareAndSwapInt32(&diagnosing, 0, 1) {
+		fmt.Fprintln(os.Stderr, "senlog:", err)
+		return
+	}
+	defer atomic.StoreInt32(&diagnosing, 0)
+
+	fn(err)
+}
@@ -0,0 +1,255 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// logger name of digest events, they are not counted by digest destinations
+const digestLoggerName = loggerName + ".digest"
+
+// period of a DigestTransport created with a period of 0 or less
+const DefaultDigestPeriod = time.Hour
+
+// distinct messages and error types counted per period, others count as "(other)"
+const maxDigestKeys = 1000
+
+// Digest summarizes the events of a period
+type Digest struct {
+	From, To    time.Time
+	Total       int
+	Levels      map[string]int // events by level name
+	TopMessages []DigestCount  // most frequent messages, most frequent first
+	TopErrors   []DigestCount  // most frequent error types
+}
+
+type DigestCount struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// String formats the digest as plain text, e.g. for an email body
+func (d Digest) String() string {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d events from %s to %s\n", d.Total, d.From.Format(time.RFC3339), d.To.Format(time.RFC3339))
+
+	b.WriteString("\nBy level:\n")
+	for _, l := range sentryLevels {
+		if n, ok := d.Levels[levelName(l)]; ok {
+			fmt.Fprintf(&b, "  %-8s %d\n", levelName(l), n)
+		}
+	}
+	for _, section := range []struct {
+		title  string
+		counts []DigestCount
+	}{{"Top messages", d.TopMessages}, {"Top errors", d.TopErrors}} {
+		if len(section.counts) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", section.title)
+		for _, c := range section.counts {
+			fmt.Fprintf(&b, "  %6d  %s\n", c.Count, c.Text)
+		}
+	}
+	return b.String()
+}
+
+// DigestTransport aggregates the events of its level and up into a Digest
+// every period, e.g. hourly, for services nobody watches in real time. The
+// digest is logged as an INF event to all destinations, or handed to Send if
+// set, see EmailDigest. Periods without events are skipped. Close, called by
+// Shutdown, delivers the digest of the running period. A period of 0 or less
+// is DefaultDigestPeriod.
+type DigestTransport struct {
+	Logger
+
+	TopN int          // entries of the top lists, 10 by default
+	Send func(Digest) // delivers digests instead of logging them
+
+	mu       sync.Mutex
+	from     time.Time
+	levels   map[string]int
+	messages map[string]int
+	errors   map[string]int
+	total    int
+
+	done chan struct{}
+	once sync.Once
+}
+
+func NewDigestTransport(period time.Duration, minLogLevel Level) *DigestTransport {
+
+	if period <= 0 {
+		period = DefaultDigestPeriod
+	}
+
+	t := &DigestTransport{TopN: 10, done: make(chan struct{})}
+	t.SetLogLevel(minLogLevel)
+	t.reset()
+
+	go func() {
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				t.deliver()
+			case <-t.done:
+				return
+			}
+		}
+	}()
+
+	return t
+}
+
+func (t *DigestTransport) reset() {
+	t.from = now()
+	t.levels = make(map[string]int)
+	t.messages = make(map[string]int)
+	t.errors = make(map[string]int)
+	t.total = 0
+}
+
+func (t *DigestTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *DigestTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		// audit and debug sampled events pass the level of Call, they don't belong to the digest
+		if ev.Logger == digestLoggerName || senlogLevels[ev.Level] < t.MinLogLevel() {
+			return
+		}
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.total++
+		t.levels[levelName(ev.Level)]++
+		count(t.messages, ev.Message)
+		if len(ev.Exception) > 0 {
+			count(t.errors, ev.Exception[len(ev.Exception)-1].Type)
+		}
+	}, ev)
+}
+
+func count(m map[string]int, k string) {
+	if _, ok := m[k]; !ok && len(m) >= maxDigestKeys {
+		k = "(other)"
+	}
+	m[k]++
+}
+
+// takes the digest of the running period and starts the next one
+func (t *DigestTransport) digest() (Digest, bool) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d := Digest{
+		From:        t.from,
+		To:          now(),
+		Total:       t.total,
+		Levels:      t.levels,
+		TopMessages: top(t.messages, t.TopN),
+		TopErrors:   top(t.errors, t.TopN),
+	}
+	empty := t.total == 0
+	t.reset()
+	return d, !empty
+}
+
+func (t *DigestTransport) deliver() {
+
+	d, ok := t.digest()
+	if !ok {
+		return
+	}
+
+	if t.Send != nil {
+		t.Send(d)
+		return
+	}
+
+	x := Cxt("digest").
+		Set("from", d.From.UTC().Format(time.RFC3339)).
+		Set("to", d.To.UTC().Format(time.RFC3339)).
+		Set("total", d.Total).
+		Set("levels", d.Levels).
+		Set("top_messages", d.TopMessages).
+		Set("top_errors", d.TopErrors)
+
+	captureWith(context.Background(), INFO, nil, x, fmt.Sprintf("Digest: %d events", d.Total), func(r *Record) {
+		r.Logger = digestLoggerName
+	})
+}
+
+// most frequent n keys of m
+func top(m map[string]int, n int) []DigestCount {
+
+	if n <= 0 {
+		n = 10
+	}
+	counts := make([]DigestCount, 0, len(m))
+	for k, c := range m {
+		counts = append(counts, DigestCount{Text: k, Count: c})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Text < counts[j].Text
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+func (t *DigestTransport) Flush(time.Duration) bool {
+	return true
+}
+
+// Close stops the digest timer and delivers the digest of the running period
+func (t *DigestTransport) Close() error {
+
+	t.once.Do(func() {
+		close(t.done)
+		t.deliver()
+	})
+	return nil
+}
+
+// EmailDigest returns a DigestTransport.Send mailing digests as plain text
+// through the SMTP server at addr, e.g. "smtp.example.com:587"
+func EmailDigest(addr string, auth smtp.Auth, from string, to ...string) func(Digest) {
+
+	return func(d Digest) {
+
+		msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Log digest: %d events\r\n\r\n%s",
+			from, strings.Join(to, ", "), d.Total, strings.ReplaceAll(d.String(), "\n", "\r\n"))
+
+		if err := smtp.SendMail(addr, auth, from, to, []byte(msg)); err != nil {
+			diagnose(fmt.Errorf("could not mail digest: %w", err))
+		}
+	}
+}
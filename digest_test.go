@@ -0,0 +1,35 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestDigestCountsOnlyItsLevel(t *testing.T) {
+
+	quiet(t)
+	digest := NewDigestTransport(0, WARN)
+
+	var got Digest
+	digest.Send = func(d Digest) { got = d }
+
+	digest.SendEvent(&sentry.Event{Level: sentry.LevelInfo, Logger: auditLoggerName, Message: "audit"})
+	digest.SendEvent(&sentry.Event{Level: sentry.LevelDebug, Tags: map[string]string{debugSampledTag: "true"}, Message: "sampled"})
+	digest.SendEvent(&sentry.Event{Level: sentry.LevelError, Message: "failed"})
+	digest.Close()
+
+	if got.Total != 1 || len(got.TopMessages) != 1 || got.TopMessages[0].Text != "failed" {
+		t.Errorf("digest of events below WARN: %+v", got)
+	}
+}
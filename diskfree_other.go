@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || dragonfly)
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "errors"
+
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.New("free disk space can't be checked on this platform")
+}
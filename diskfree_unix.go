@@ -0,0 +1,25 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "syscall"
+
+// bytes available to unprivileged users on the volume of dir
+func diskFree(dir string) (uint64, error) {
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,109 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchDiskSpace checks the free space of the volumes of the files of t every
+// interval and logs a WRN when it falls below minFree bytes, and an INF when
+// it recovered. With drop, t stops writing while space is low instead of
+// failing writes once the disk is full. The returned func stops the watchdog,
+// an interval of 0 or less doesn't watch. Only transports of NewFileTransport have files, free space can't be checked
+// on Windows and other platforms without statfs.
+//
+//	t, err := senlog.NewFileTransport("app.log", "app.log", senlog.INFO)
+//	...
+//	stop := t.WatchDiskSpace(time.Minute, 100<<20, true)
+//	defer stop()
+func (t *ioTransport) WatchDiskSpace(interval time.Duration, minFree uint64, drop bool) (stop func()) {
+
+	if interval <= 0 {
+		return func() {}
+	}
+
+	dirs := make(map[string]bool)
+	for _, c := range t.files {
+		if f, ok := c.(*os.File); ok {
+			dirs[filepath.Dir(f.Name())] = true
+		}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		w := diskWatch{t: t, minFree: minFree, drop: drop, low: make(map[string]bool), failed: make(map[string]bool)}
+		for {
+			for dir := range dirs {
+				w.check(dir)
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				atomic.StoreInt32(&t.diskLow, 0)
+				return
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }
+}
+
+type diskWatch struct {
+	t       *ioTransport
+	minFree uint64
+	drop    bool
+	low     map[string]bool // dirs low on space at the last check
+	failed  map[string]bool // dirs which couldn't be checked, warned once
+}
+
+func (w *diskWatch) check(dir string) {
+
+	free, err := diskFree(dir)
+	if err != nil {
+		if !w.failed[dir] {
+			w.failed[dir] = true
+			Set("path", dir).Set("error", err.Error()).WRN("Disk watchdog can't check free space")
+		}
+		return
+	}
+	w.failed[dir] = false
+
+	wasLow := w.low[dir]
+	w.low[dir] = free < w.minFree
+
+	x := Set("path", dir).Set("free", free).Set("min_free", w.minFree)
+	switch {
+	case w.low[dir] && !wasLow:
+		x.Set("dropping", w.drop).WRN("Low disk space for log files")
+	case !w.low[dir] && wasLow:
+		x.INF("Disk space for log files recovered")
+	}
+
+	if w.drop {
+		var low int32
+		for _, l := range w.low {
+			if l {
+				low = 1
+			}
+		}
+		atomic.StoreInt32(&w.t.diskLow, low) // after the WRN, which is still written
+	}
+}
@@ -0,0 +1,33 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchDiskSpaceWithoutInterval(t *testing.T) {
+
+	quiet(t)
+	file := filepath.Join(t.TempDir(), "app.log")
+	tr, err := NewFileTransport(file, file, INFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		tr.WatchDiskSpace(interval, 1<<62, true)() // time.NewTicker panics for these
+	}
+	time.Sleep(10 * time.Millisecond) // a watchdog goroutine would have started
+}
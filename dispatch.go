@@ -0,0 +1,181 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var queued sync.WaitGroup // events in destination queues
+
+// SetParallelDispatch sends events to all destinations concurrently, so a slow
+// destination, e.g. Sentry over HTTP, doesn't delay the others. Each
+// destination gets a queue of queueSize events, sent in order by one
+// goroutine, ERROR and FATAL events first, see SetPriorityLanes. While a
+// destination's queue is full, logging waits for room once the event was handed
+// to the other destinations, unless its backpressure is set to drop events, see
+// SetBackpressure. Queued events are sent even if the caller's context is done
+// meanwhile, and dropped if the destination is removed. 0 dispatches
+// synchronously again. A queue is made on the first event dispatched in
+// parallel to its destination, a later queueSize only applies to destinations
+// without a queue yet, e.g. added afterwards.
+func SetParallelDispatch(queueSize int) {
+
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	updateSettings(func(s *settings) { s.parallelDispatch = queueSize })
+}
+
+// dispatch sends r to the destination, synchronously or through its queue.
+// With ordering the events of different sequences are sent concurrently. If
+// the queue is full and blocks, it returns a func waiting for room, which
+// broadcast calls after dispatching r to the other destinations.
+func (d *destination) dispatch(ctx context.Context, r *Record, sequence string) (wait func()) {
+
+	if d.sampledOut(r) {
+		d.filter(r, DroppedBySampler)
+		return nil
+	}
+
+	t := d.turn(sequence)
+
+	size := loadSettings().parallelDispatch
+	if size == 0 {
+		d.sendTurn(ctx, r, t)
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		d.drop(r, DroppedByContext)
+		d.skip(t)
+		return nil
+	}
+
+	select {
+	case <-d.stop: // removed while r was logged
+		d.drop(r, DroppedByRemoval)
+		d.skip(t)
+		return nil
+	default:
+	}
+
+	overflow := d.overflow().Overflow
+	if priority(r) {
+		overflow = Block // never dropped by backpressure
+	}
+
+	if t.done != nil { // in order, priority or not
+		return d.acquire(d.sendSlots(size), r, t, overflow)
+	}
+
+	q, urgent := d.queue(size)
+	if priority(r) {
+		q = urgent
+	}
+	return d.enqueue(q, r, overflow)
+}
+
+// queue of the destination and its priority lane, started on first use with size
+func (d *destination) queue(size int) (chan *Record, chan *Record) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.events == nil {
+		d.events = make(chan *Record, size)
+		d.urgent = make(chan *Record, size)
+		go d.sendQueued(d.events, d.urgent)
+	}
+	return d.events, d.urgent
+}
+
+// sends queued events in order, events of the priority lane first, until the
+// destination is removed
+func (d *destination) sendQueued(events chan *Record, urgent chan *Record) {
+
+	for {
+		var r *Record
+		select {
+		case r = <-urgent:
+		default:
+			select {
+			case r = <-urgent:
+			case r = <-events:
+			case <-d.stop:
+				d.discard(urgent)
+				d.discard(events)
+				return
+			}
+		}
+
+		d.send(context.Background(), r)
+		queued.Done()
+		pending.Done()
+	}
+}
+
+// drops the events left in a queue of a removed destination
+func (d *destination) discard(q chan *Record) {
+
+	for {
+		select {
+		case r := <-q:
+			d.dequeued(r, DroppedByRemoval)
+		default:
+			return
+		}
+	}
+}
+
+// bounds the concurrent sends of ordered parallel dispatch to size
+func (d *destination) sendSlots(size int) chan struct{} {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.slots == nil {
+		d.slots = make(chan struct{}, size)
+	}
+	return d.slots
+}
+
+// sends r in its turn, in the slot taken
+func (d *destination) sendInSlot(slots chan struct{}, r *Record, t turn) {
+
+	d.highWater(d.overflow(), len(slots), cap(slots))
+
+	queued.Add(1)
+	pending.Add(1)
+	go func() {
+		d.sendTurn(context.Background(), r, t)
+		<-slots
+		queued.Done()
+		pending.Done()
+	}()
+}
+
+// waits at most timeout for the queued events to be sent
+func drainQueues(timeout time.Duration) {
+
+	drained := make(chan struct{})
+	go func() {
+		queued.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+	}
+}
@@ -0,0 +1,358 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func parallelDispatch(t *testing.T, queueSize int) {
+	SetParallelDispatch(queueSize)
+	t.Cleanup(func() { SetParallelDispatch(0) })
+}
+
+// goroutines sending queued events
+func queueSenders() int {
+
+	buf := make([]byte, 1<<20)
+	return strings.Count(string(buf[:runtime.Stack(buf, true)]), "created by github.com/ejazmughal/senlog.(*destination).queue")
+}
+
+func TestRemovedDestinationStopsItsQueue(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 4)
+	before := queueSenders()
+
+	if err := AddDestination("queued", sentry.ClientOptions{Transport: newRecordingTransport(DEBUG)}); err != nil {
+		t.Fatal(err)
+	}
+	INF("starts the queue")
+	RemoveDestination("queued")
+
+	for deadline := time.Now().Add(time.Second); queueSenders() > before; {
+		if time.Now().After(deadline) {
+			t.Fatal("queue goroutine of a removed destination still running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventQueuedAfterRemovalIsDropped(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 4)
+	before := queueSenders()
+
+	if err := AddDestination("queued", sentry.ClientOptions{Transport: newRecordingTransport(DEBUG)}); err != nil {
+		t.Fatal(err)
+	}
+	d, _ := lookup("queued")
+	q, _ := d.queue(4)
+	RemoveDestination("queued")
+	for queueSenders() > before {
+		time.Sleep(time.Millisecond)
+	}
+
+	// dispatch checked the destination before it was removed
+	d.enqueue(q, &Record{Level: INFO, Msg: "late"}, Block)
+
+	if !waitPending(time.Second) {
+		t.Error("event queued after the queue goroutine ended is still pending")
+	}
+	if n := atomic.LoadUint64(&d.dropped); n != 1 {
+		t.Errorf("%d dropped, want the late event", n)
+	}
+}
+
+func TestFullQueueDoesntHoldBackOthers(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 1)
+
+	hung := newHungTransport(t)
+	rec := newRecordingTransport(DEBUG)
+	if err := AddDestination("hung", sentry.ClientOptions{Transport: hung}); err != nil {
+		t.Fatal(err)
+	}
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	hung.hang()
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		INF("sending")
+		INF("queued")
+		INF("full") // waits for room in the queue of hung
+	}()
+
+	for deadline := time.Now().Add(time.Second); !contains(rec.Messages(), "full"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("other destination waited for the full queue, got %q", rec.Messages())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	RemoveDestination("hung")
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("log call still waits for the queue of a removed destination")
+	}
+}
+
+func TestParallelDispatchKeepsOrderPerDestination(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 8)
+
+	first, second := newRecordingTransport(DEBUG), newRecordingTransport(DEBUG)
+	addTestDestination(t, "first", sentry.ClientOptions{Transport: first})
+	addTestDestination(t, "second", sentry.ClientOptions{Transport: second})
+
+	var want []string
+	for i := 0; i < 100; i++ {
+		msg := strconv.Itoa(i)
+		want = append(want, msg)
+		INF(msg)
+	}
+	drainQueues(time.Second)
+
+	for _, rec := range []*recordingTransport{first, second} {
+		if got := rec.Messages(); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("sent %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSlowDestinationDoesntDelayOthers(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 4)
+
+	hung := newHungTransport(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "hung", sentry.ClientOptions{Transport: hung})
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	hung.hang()
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		INF("while hung")
+	}()
+
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("log call waited for the hung destination")
+	}
+	for deadline := time.Now().Add(time.Second); !contains(rec.Messages(), "while hung"); {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %q, want the event sent beside the hung destination", rec.Messages())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// records the messages sent, holds the message "first" until released
+type gatedTransport struct {
+	recordingTransport
+	release chan struct{}
+}
+
+func (t *gatedTransport) SendEvent(ev *sentry.Event) {
+	if ev.Message == "first" {
+		<-t.release
+	}
+	t.recordingTransport.SendEvent(ev)
+}
+
+func TestPriorityKeepsItsPlaceInOrderedSequence(t *testing.T) {
+
+	quiet(t)
+	parallelDispatch(t, 1)
+	SetOrdering(true)
+	t.Cleanup(func() { SetOrdering(false) })
+
+	gated := &gatedTransport{release: make(chan struct{})}
+	addTestDestination(t, "gated", sentry.ClientOptions{Transport: gated})
+	SetBackpressure("gated", Backpressure{Overflow: DropNewest})
+
+	var mu sync.Mutex
+	var drops []Drop
+	SetFilterDebug(func(d Drop) {
+		if d.Destination == "gated" {
+			mu.Lock()
+			drops = append(drops, d)
+			mu.Unlock()
+		}
+	})
+	t.Cleanup(func() { SetFilterDebug(nil) })
+
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		INF("first")                        // takes the only send slot
+		ERR(errors.New("second"), "second") // waits for it, not dropped
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	close(gated.release)
+	<-logged
+	if !waitPending(time.Second) {
+		t.Fatal("sends still running")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(drops) > 0 {
+		t.Errorf("dropped %v", drops)
+	}
+	if msgs := gated.Messages(); len(msgs) != 2 || msgs[0] != "first" || msgs[1] != "second" {
+		t.Errorf("sent %q, want first, second", msgs)
+	}
+}
+
+func TestBackpressureOfFullQueue(t *testing.T) {
+
+	for _, test := range []struct {
+		name     string
+		overflow Overflow
+		want     string
+	}{
+		{"DropNewest", DropNewest, "first a b"},
+		{"DropOldest", DropOldest, "first b c"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+
+			quiet(t)
+			parallelDispatch(t, 2)
+
+			gated := &gatedTransport{release: make(chan struct{})}
+			addTestDestination(t, "gated", sentry.ClientOptions{Transport: gated})
+			highWater := make(chan int, 4)
+			SetBackpressure("gated", Backpressure{Overflow: test.overflow, HighWater: 1, OnHighWater: func(key string, queued int, size int) {
+				highWater <- queued
+			}})
+			d, _ := lookup("gated")
+
+			INF("first") // held by the transport
+			for q, _ := d.queue(2); len(q) > 0; {
+				time.Sleep(time.Millisecond)
+			}
+			INF("a")
+			INF("b") // fills the queue
+			INF("c")
+
+			close(gated.release)
+			drainQueues(time.Second)
+
+			if got := strings.Join(gated.Messages(), " "); got != test.want {
+				t.Errorf("sent %q, want %q", got, test.want)
+			}
+			if n := Dropped("gated"); n != 1 {
+				t.Errorf("dropped %d, want 1", n)
+			}
+			select {
+			case n := <-highWater:
+				if n != 2 {
+					t.Errorf("high water at %d queued, want 2", n)
+				}
+			case <-time.After(time.Second):
+				t.Error("high water not reported")
+			}
+		})
+	}
+}
+
+// records the messages sent, holds the first events of each sequence back a bit
+type jitterTransport struct {
+	recordingTransport
+}
+
+func (t *jitterTransport) SendEvent(ev *sentry.Event) {
+	if strings.HasSuffix(ev.Message, " 0") {
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.recordingTransport.SendEvent(ev)
+}
+
+func TestOrderingKeepsSequencesInOrder(t *testing.T) {
+
+	quiet(t)
+	SetOrdering(true)
+	t.Cleanup(func() { SetOrdering(false) })
+
+	jitter := new(jitterTransport)
+	addTestDestination(t, "jitter", sentry.ClientOptions{Transport: jitter})
+	SetSendTimeout("jitter", time.Millisecond) // slow sends go on in the background
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				INF(fmt.Sprintf("goroutine %d %d", g, i))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// a request logging from one goroutine after another
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req")
+	for i := 0; i < 3; i++ {
+		done := make(chan struct{})
+		go func(i int) {
+			defer close(done)
+			INFCtx(ctx, fmt.Sprintf("request %d", i))
+		}(i)
+		<-done
+	}
+
+	if !waitPending(time.Second) {
+		t.Fatal("sends still running")
+	}
+
+	next := make(map[string]int) // next index by sequence
+	for _, msg := range jitter.Messages() {
+		i := strings.LastIndexByte(msg, ' ')
+		seq, n := msg[:i], msg[i+1:]
+		if want := strconv.Itoa(next[seq]); n != want {
+			t.Fatalf("%s sent after %s %d, want in order", msg, seq, next[seq]-1)
+		}
+		next[seq]++
+	}
+	if len(next) != 5 {
+		t.Errorf("got %d sequences, want 5", len(next))
+	}
+}
+
+func contains(msgs []string, msg string) bool {
+	for _, m := range msgs {
+		if m == msg {
+			return true
+		}
+	}
+	return false
+}
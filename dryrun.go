@@ -0,0 +1,120 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// DryRunFunc is told which event a destination would deliver
+type DryRunFunc func(destinationKey string, ev *sentry.Event)
+
+var dryRun atomic.Value // DryRunFunc, nil delivers events
+
+// SetDryRun validates routing and scrubbing rules without sending. Events run
+// through the whole pipeline, routing, PII policies, the destination's
+// BeforeSend and sampling, but instead of its transport report is called
+// with what would be delivered. A nil report delivers events again.
+//
+//	senlog.SetDryRun(senlog.DryRunReport(os.Stderr))
+func SetDryRun(report DryRunFunc) {
+	dryRun.Store(report)
+}
+
+func dryRunReport() DryRunFunc {
+	report, _ := dryRun.Load().(DryRunFunc)
+	return report
+}
+
+// DryRunReport writes one line per event a destination would deliver, the
+// destination key followed by the event as JSON
+func DryRunReport(w io.Writer) DryRunFunc {
+
+	var mu sync.Mutex
+	return func(destinationKey string, ev *sentry.Event) {
+
+		b, err := formatDocument(JSONFormat, ev)
+		if err != nil {
+			diagnose(fmt.Errorf("could not encode event %s: %w", ev.EventID, err))
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = fmt.Fprintf(w, "dry run: %s %s\n", destinationKey, b)
+		diagnose(err)
+	}
+}
+
+// dry run client of a destination, it is recreated when the client changes
+type dryClient struct {
+	mu     sync.Mutex
+	of     *sentry.Client
+	client *sentry.Client
+}
+
+// sends r through a copy of the destination's client which reports instead of sending
+func (d *destination) dryRun(r *Record) {
+
+	client := d.hub.Client()
+
+	d.dry.mu.Lock()
+	if d.dry.of != client {
+		options := client.Options()
+		options.Transport = &dryRunTransport{key: d.key, transport: client.Transport}
+		dry, err := sentry.NewClient(options)
+		if err != nil {
+			d.dry.mu.Unlock()
+			diagnose(err)
+			return
+		}
+		d.dry.of, d.dry.client = client, dry
+	}
+	dry := d.dry.client
+	d.dry.mu.Unlock()
+
+	ev := applySentryScope(r.scope, d.own(r).event())
+	if ev == nil { // dropped by the scope
+		return
+	}
+
+	hub := d.hub.Clone()
+	hub.BindClient(dry)
+	hub.CaptureEvent(ev)
+}
+
+// dryRunTransport reports the events the destination's transport would log
+type dryRunTransport struct {
+	key       string
+	transport sentry.Transport
+}
+
+func (t *dryRunTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *dryRunTransport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+func (t *dryRunTransport) SendEvent(ev *sentry.Event) {
+
+	if l, ok := t.transport.(LeveledLogger); ok && senlogLevels[ev.Level] < l.MinLogLevel() && !levelExempt(ev) {
+		return
+	}
+	if report := dryRunReport(); report != nil {
+		report(t.key, ev)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DumpConfig writes the current configuration, see CurrentConfig, as "json"
+// or "yaml", e.g. to attach the logging setup to a bug report or to audit
+// config drift. Durations are written in nanoseconds.
+func DumpConfig(w io.Writer, format string) error {
+
+	b, err := json.MarshalIndent(CurrentConfig(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(format) {
+	case "json":
+		_, err = w.Write(append(b, '\n'))
+		return err
+	case "yaml", "yml":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber() // durations exceed float64 precision
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		writeYAMLMap(&out, doc, 0)
+		_, err = w.Write(out.Bytes())
+		return err
+	}
+	return fmt.Errorf("senlog: unknown config format %q, want json or yaml", format)
+}
+
+// keys written without quotes
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./-]*$`)
+
+func writeYAMLMap(b *bytes.Buffer, m map[string]interface{}, indent int) {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		b.WriteString(strings.Repeat("  ", indent))
+		if plainYAMLKey.MatchString(k) {
+			b.WriteString(k)
+		} else {
+			writeYAMLScalar(b, k)
+		}
+		b.WriteByte(':')
+		writeYAMLValue(b, m[k], indent)
+	}
+}
+
+// writes v after its key, nested maps and lists on the following lines
+func writeYAMLValue(b *bytes.Buffer, v interface{}, indent int) {
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteByte('\n')
+		writeYAMLMap(b, v, indent+1)
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteByte('\n')
+		for _, e := range v {
+			b.WriteString(strings.Repeat("  ", indent+1))
+			b.WriteByte('-')
+			writeYAMLValue(b, e, indent+1)
+		}
+	default:
+		b.WriteByte(' ')
+		writeYAMLScalar(b, v)
+		b.WriteByte('\n')
+	}
+}
+
+// JSON scalars are valid YAML, strings are double quoted
+func writeYAMLScalar(b *bytes.Buffer, v interface{}) {
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		diagnose(err)
+		b.WriteString("null")
+		return
+	}
+	b.Write(j)
+}
@@ -0,0 +1,74 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestDumpConfigJSON(t *testing.T) {
+
+	quiet(t) // the console logs nothing, FATAL+1
+	addTestDestination(t, "dump", sentry.ClientOptions{Transport: newRecordingTransport(WARN)})
+	SetSendTimeout("dump", time.Second)
+
+	var buf bytes.Buffer
+	if err := DumpConfig(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var c Config
+	if err := json.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if dc := c.Destinations["dump"]; dc.MinLevel != WARN || dc.SendTimeout != time.Second {
+		t.Errorf("dumped %+v, want WARN and a timeout of 1s", dc)
+	}
+	if got := c.Destinations["console"].MinLevel; got != FATAL+1 {
+		t.Errorf("console dumped at %v, want %v", got, Level(FATAL+1))
+	}
+}
+
+func TestDumpConfigYAML(t *testing.T) {
+
+	quiet(t)
+	addTestDestination(t, "dump", sentry.ClientOptions{Transport: newRecordingTransport(WARN)})
+	SetSendTimeout("dump", time.Second)
+
+	var buf bytes.Buffer
+	if err := DumpConfig(&buf, "YAML"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"Destinations:\n",
+		"  dump:\n",
+		"    MinLevel: \"WARN\"\n",
+		"    SendTimeout: 1000000000\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("no line %q in\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestDumpConfigUnknownFormat(t *testing.T) {
+
+	if err := DumpConfig(new(bytes.Buffer), "toml"); err == nil {
+		t.Error("dumped as toml, want an error")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+)
+
+// DuplicateKeys selects what Set does with a key already set in the same context
+type DuplicateKeys int32
+
+const (
+	OverwriteDuplicates DuplicateKeys = iota // the last value wins, the default
+	SuffixDuplicates                         // later values are kept as key_2, key_3, ...
+)
+
+// SetDuplicateKeys sets how a key set twice in one context is handled. In strict
+// mode a warning with the call site is logged for every duplicate key.
+func SetDuplicateKeys(d DuplicateKeys) {
+	updateSettings(func(s *settings) { s.duplicateKeys = d })
+}
+
+// key under which a duplicate of k is stored in fields
+func duplicateKey(context string, fields map[string]interface{}, k string) string {
+
+	if strictMode() {
+		x := Cxt("senlog.duplicate").Set("context", context).Set("key", k)
+		if st := stacktrace(); st != nil && len(st.Frames) > 0 {
+			f := st.Frames[len(st.Frames)-1]
+			x = x.Set("caller", fmt.Sprintf("%s:%d", f.AbsPath, f.Lineno))
+		}
+		x.WRN("Context key set twice")
+	}
+
+	if loadSettings().duplicateKeys != SuffixDuplicates {
+		return k
+	}
+
+	for n := 2; ; n++ {
+		suffixed := fmt.Sprintf("%s_%d", k, n)
+		if _, exists := fields[suffixed]; !exists {
+			return suffixed
+		}
+	}
+}
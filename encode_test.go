@@ -0,0 +1,98 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEncodeJSONMatchesMarshal(t *testing.T) {
+
+	values := []interface{}{
+		nil,
+		"",
+		"plain",
+		"quote \" backslash \\ slash /",
+		"html <a href=\"x\">&amp;</a>",
+		"control \x00 \x01 \x1f \t \n \r \x7f",
+		"separators \u2028 \u2029",
+		"invalid \xff utf-8 \xc3",
+		"unicode é 日本 😀",
+		true,
+		false,
+		0,
+		-42,
+		int8(math.MinInt8),
+		int16(math.MaxInt16),
+		int32(math.MinInt32),
+		int64(math.MaxInt64),
+		uint(7),
+		uint8(math.MaxUint8),
+		uint16(math.MaxUint16),
+		uint32(math.MaxUint32),
+		uint64(math.MaxUint64),
+		0.0,
+		math.Copysign(0, -1),
+		0.1,
+		-1.5,
+		1e20,
+		1e21,
+		123456789e15,
+		1e-6,
+		1e-7,
+		5e-324,
+		math.MaxFloat64,
+		float32(0.1),
+		float32(1e21),
+		float32(1e-7),
+		float32(math.MaxFloat32),
+		math.NaN(),
+		math.Inf(1),
+		math.Inf(-1),
+		float32(math.Inf(1)),
+		time.Date(2022, 5, 1, 12, 30, 0, 123456789, time.UTC),
+		time.Date(2022, 5, 1, 12, 30, 0, 0, time.FixedZone("", 2*3600)),
+		time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(-1, 1, 1, 0, 0, 0, 0, time.UTC),
+		map[string]interface{}{"b": 1, "a": "<x>", "c": map[string]interface{}{"z": nil}},
+		map[string]interface{}(nil),
+		map[string]string{"k\"ey": "v\u2028", "a": ""},
+		map[string]string(nil),
+		[]interface{}{1, "two", 3.5, nil, []string{"x"}},
+		[]interface{}(nil),
+		[]string{"a", "<b>"},
+		[]string(nil),
+		[]interface{}{math.NaN()},
+		struct {
+			A int    `json:"a"`
+			B string `json:"b"`
+		}{1, "<b>"},
+		time.Second,
+		[]int{1, 2},
+	}
+
+	for _, v := range values {
+		doc := map[string]interface{}{"v": v, "key <&>": "\xff"}
+
+		want, wantErr := json.Marshal(doc)
+		got, err := encodeJSON(doc)
+		if (err != nil) != (wantErr != nil) {
+			t.Errorf("%#v: error %v, json.Marshal %v", v, err, wantErr)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%#v:\n got %s\nwant %s", v, got, want)
+		}
+	}
+}
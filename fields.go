@@ -0,0 +1,130 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// field renaming applied to events on output, replaced as a whole on change
+type fieldNames struct {
+	aliases    map[string]string
+	normalize  func(string) string
+	normalized *internTable // names by field, normalize runs once per field
+}
+
+var (
+	fieldNamesMu sync.Mutex
+	fieldNaming  atomic.Value // *fieldNames
+)
+
+// SetFieldAlias renames field from to to in all events, e.g.
+// SetFieldAlias("request_id", "trace.request_id"). An alias takes precedence
+// over the normalizer. An empty to removes the alias.
+func SetFieldAlias(from, to string) {
+
+	fieldNamesMu.Lock()
+	defer fieldNamesMu.Unlock()
+
+	current := currentFieldNames()
+	updated := &fieldNames{aliases: make(map[string]string, len(current.aliases)+1), normalize: current.normalize, normalized: current.normalized}
+	for k, v := range current.aliases {
+		updated.aliases[k] = v
+	}
+	if to == "" {
+		delete(updated.aliases, from)
+	} else {
+		updated.aliases[from] = to
+	}
+	fieldNaming.Store(updated)
+}
+
+// SetFieldNormalizer renames all fields without alias with normalize, e.g.
+// SetFieldNormalizer(SnakeCase). nil disables normalization.
+func SetFieldNormalizer(normalize func(string) string) {
+
+	fieldNamesMu.Lock()
+	defer fieldNamesMu.Unlock()
+
+	current := currentFieldNames()
+	fieldNaming.Store(&fieldNames{aliases: current.aliases, normalize: normalize, normalized: new(internTable)})
+}
+
+func currentFieldNames() *fieldNames {
+	if n, ok := fieldNaming.Load().(*fieldNames); ok {
+		return n
+	}
+	return &fieldNames{}
+}
+
+// SnakeCase converts field names like "requestID" or "Request Id" to "request_id"
+func SnakeCase(s string) string {
+
+	var b strings.Builder
+	runes := []rune(s)
+
+	for i, r := range runes {
+		switch {
+		case r == ' ' || r == '-':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != ' ' && runes[i-1] != '-' && runes[i-1] != '_' && runes[i-1] != '.' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// contexts with renamed fields, the given maps are not modified
+func renameFields(contexts map[string]interface{}) map[string]interface{} {
+
+	names := currentFieldNames()
+	if len(names.aliases) == 0 && names.normalize == nil {
+		// the event gets its own map, sentry adds its contexts to it
+		copied := make(map[string]interface{}, len(contexts)+3)
+		for name, fields := range contexts {
+			copied[name] = fields
+		}
+		return copied
+	}
+
+	renamed := make(map[string]interface{}, len(contexts))
+	for name, fields := range contexts {
+
+		m, ok := fields.(map[string]interface{})
+		if !ok {
+			renamed[name] = fields
+			continue
+		}
+
+		r := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			if alias, ok := names.aliases[k]; ok {
+				k = alias
+			} else if names.normalize != nil {
+				k = names.normalized.lookup(k, names.normalize)
+			}
+			r[k] = v
+		}
+		renamed[name] = r
+	}
+
+	return renamed
+}
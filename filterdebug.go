@@ -0,0 +1,221 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropReason is why an event was not sent to a destination
+type DropReason int
+
+const (
+	DroppedByLevel        DropReason = iota + 1 // below the log level of the destination, or of all destinations
+	DroppedBySampler                            // sampled out, see SetSampling
+	DroppedByRoute                              // excluded by Except or the active profile
+	DroppedByScope                              // dropped by an event processor of the sentry scope
+	DroppedByBackpressure                       // the destination's queue was full, see SetBackpressure
+	DroppedByBreaker                            // the destination's circuit breaker was open
+	DroppedByContext                            // the caller's context was done
+	DroppedByTimeout                            // the send timed out, see SetSendTimeout
+	DroppedByShutdown                           // logged after Shutdown
+	DroppedByRemoval                            // queued when the destination was removed
+)
+
+var dropReasons = [...]string{"", "level", "sampler", "route", "scope", "backpressure", "breaker", "context", "timeout", "shutdown", "removal"}
+
+func (r DropReason) String() string {
+
+	if r <= 0 || int(r) >= len(dropReasons) {
+		return fmt.Sprintf("DropReason(%d)", int(r))
+	}
+	return dropReasons[r]
+}
+
+// Drop describes an event not sent to a destination, see SetFilterDebug
+type Drop struct {
+	Destination string // key of the destination, empty if the event reached none
+	Reason      DropReason
+	Level       Level
+	Msg         string
+}
+
+func (d Drop) String() string {
+
+	destination := "all destinations"
+	if d.Destination != "" {
+		destination = d.Destination
+	}
+	return fmt.Sprintf("%s %q dropped by %s: %s", d.Level, d.Msg, destination, d.Reason)
+}
+
+type dropKey struct {
+	destination string
+	reason      DropReason
+}
+
+var (
+	filterDebugging int32        // 1 while drops are reported, keeps the hot path at one load otherwise
+	filterDebug     atomic.Value // func(Drop)
+
+	dropsMu    sync.Mutex
+	drops      map[dropKey]uint64 // counted since the last filter report, nil if off
+	reportStop chan struct{}
+)
+
+// SetFilterDebug calls fn with every event not sent to a destination and why,
+// to debug log lines that don't show up. fn is called on the logging goroutine
+// or a sending one and must not block. nil turns it off.
+func SetFilterDebug(fn func(Drop)) {
+
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+
+	filterDebug.Store(fn)
+	updateFilterDebugging()
+}
+
+// FilterDebug returns the function set by SetFilterDebug, nil if none, e.g. to
+// restore it after replacing it for a while
+func FilterDebug() func(Drop) {
+
+	fn, _ := filterDebug.Load().(func(Drop))
+	return fn
+}
+
+// SetFilterReport reports the events dropped since the last report to the
+// diagnostics every interval, counted by destination and reason, see
+// SetDiagnostics. Intervals without drops are not reported. 0 turns it off.
+func SetFilterReport(interval time.Duration) {
+
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+
+	if reportStop != nil {
+		close(reportStop)
+		reportStop, drops = nil, nil
+	}
+
+	if interval > 0 {
+		reportStop = make(chan struct{})
+		drops = make(map[dropKey]uint64)
+		go reportDrops(interval, reportStop)
+	}
+	updateFilterDebugging()
+}
+
+// called with dropsMu held
+func updateFilterDebugging() {
+
+	fn, _ := filterDebug.Load().(func(Drop))
+	on := int32(0)
+	if fn != nil || drops != nil {
+		on = 1
+	}
+	atomic.StoreInt32(&filterDebugging, on)
+}
+
+func reportDrops(interval time.Duration, stop chan struct{}) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		dropsMu.Lock()
+		if len(drops) == 0 {
+			dropsMu.Unlock()
+			continue
+		}
+		counts := drops
+		drops = make(map[dropKey]uint64)
+		dropsMu.Unlock()
+
+		diagnose(fmt.Errorf("filter report of the last %s: %s", interval, formatDrops(counts)))
+	}
+}
+
+// counts by destination, e.g. console: 12 level, 3 sampler; sentry: 40 level
+func formatDrops(counts map[dropKey]uint64) string {
+
+	keys := make([]dropKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].destination != keys[j].destination {
+			return keys[i].destination < keys[j].destination
+		}
+		return keys[i].reason < keys[j].reason
+	})
+
+	var b strings.Builder
+	for i, k := range keys {
+		switch {
+		case i == 0:
+		case k.destination != keys[i-1].destination:
+			b.WriteString("; ")
+		default:
+			b.WriteString(", ")
+		}
+		if i == 0 || k.destination != keys[i-1].destination {
+			destination := k.destination
+			if destination == "" {
+				destination = "all destinations"
+			}
+			b.WriteString(destination + ": ")
+		}
+		fmt.Fprintf(&b, "%d %s", counts[k], k.reason)
+	}
+	return b.String()
+}
+
+// reports an event not sent to the destination, "" for all destinations
+func reportDrop(destinationKey string, reason DropReason, level Level, msg string) {
+
+	if atomic.LoadInt32(&filterDebugging) == 0 {
+		return
+	}
+
+	dropsMu.Lock()
+	if drops != nil {
+		drops[dropKey{destinationKey, reason}]++
+	}
+	dropsMu.Unlock()
+
+	if fn, _ := filterDebug.Load().(func(Drop)); fn != nil {
+		fn(Drop{Destination: destinationKey, Reason: reason, Level: level, Msg: msg})
+	}
+}
+
+// counts r as dropped by the destination
+func (d *destination) drop(r *Record, reason DropReason) {
+
+	atomic.AddUint64(&d.dropped, 1)
+	reportDrop(d.key, reason, r.Level, r.Msg)
+}
+
+// counts r as filtered by the destination
+func (d *destination) filter(r *Record, reason DropReason) {
+
+	atomic.AddUint64(&d.filtered, 1)
+	reportDrop(d.key, reason, r.Level, r.Msg)
+}
@@ -0,0 +1,325 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Format is the output format of io transports
+type Format int
+
+const (
+	TextFormat         Format = iota // human readable lines, the default
+	ECSFormat                        // Elastic Common Schema JSON, one document per line
+	OTelFormat                       // OpenTelemetry log data model JSON, one record per line
+	JSONFormat                       // flat JSON, one object per line
+	CloudLoggingFormat               // Google Cloud Logging structured JSON, e.g. on Cloud Run
+	LambdaFormat                     // AWS Lambda JSON log format, read by CloudWatch
+)
+
+const ecsVersion = "1.6.0"
+
+// contexts added by sentry to every event and the trace context, not written
+// by io transports
+func sentryContext(name string) bool {
+	return name == "os" || name == "device" || name == "runtime" || name == "trace"
+}
+
+func formatDocument(f Format, ev *sentry.Event) ([]byte, error) {
+
+	switch f {
+	case ECSFormat:
+		return encodeJSON(ecsDocument(ev))
+	case OTelFormat:
+		return encodeJSON(otelRecord(ev))
+	case JSONFormat:
+		return encodeJSON(jsonObject(ev))
+	case CloudLoggingFormat:
+		return encodeJSON(cloudLoggingEntry(ev))
+	case LambdaFormat:
+		return encodeJSON(lambdaRecord(ev))
+	}
+	return nil, fmt.Errorf("senlog: unknown document format %d", f)
+}
+
+// level names as used by most log shippers
+func levelName(l sentry.Level) string {
+
+	switch l {
+	case sentry.LevelWarning:
+		return "warn"
+	}
+	return string(l)
+}
+
+// ECS document of an event: level to log.level, error to error.*, the default
+// context and tags to labels, named contexts to custom fields under their name
+func ecsDocument(ev *sentry.Event) map[string]interface{} {
+
+	doc := map[string]interface{}{
+		"@timestamp":  ev.Timestamp.UTC().Format(time.RFC3339Nano),
+		"log.level":   levelName(ev.Level),
+		"log.logger":  ev.Logger,
+		"message":     ev.Message,
+		"ecs.version": ecsVersion,
+	}
+
+	if ev.EventID != "" {
+		doc["event.id"] = string(ev.EventID)
+	}
+
+	if len(ev.Exception) > 0 {
+		ex := ev.Exception[len(ev.Exception)-1]
+		doc["error.message"] = ex.Value
+		doc["error.type"] = ex.Type
+		if ev.Exception[0].Stacktrace != nil {
+			doc["error.stack_trace"] = stackTraceString(ev.Exception[0].Stacktrace)
+		}
+	}
+
+	labels := make(map[string]interface{})
+	for k, v := range ev.Tags {
+		labels[k] = v
+	}
+
+	for name, fields := range ev.Contexts {
+		if sentryContext(name) {
+			continue
+		}
+		if name == "Default Context" {
+			if m, ok := fields.(map[string]interface{}); ok {
+				for k, v := range m {
+					labels[k] = v
+				}
+				continue
+			}
+		}
+		doc[name] = fields
+	}
+
+	if len(labels) > 0 {
+		doc["labels"] = labels
+	}
+
+	return doc
+}
+
+// stacktrace as text, most recent call first like Go panics
+func stackTraceString(st *sentry.Stacktrace) string {
+
+	var b strings.Builder
+	for i := len(st.Frames) - 1; i >= 0; i-- {
+		f := st.Frames[i]
+		fn := f.Function
+		if f.Module != "" {
+			fn = f.Module + "." + fn
+		}
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", fn, f.AbsPath, f.Lineno)
+	}
+	return b.String()
+}
+
+// OpenTelemetry severity numbers of sentry levels
+var otelSeverity = map[sentry.Level]int{
+	sentry.LevelDebug:   5,
+	sentry.LevelInfo:    9,
+	sentry.LevelWarning: 13,
+	sentry.LevelError:   17,
+	sentry.LevelFatal:   21,
+}
+
+// OTel log record of an event following the log and semantic conventions:
+// contexts become attributes prefixed with the context name (default context
+// unprefixed), errors exception.* attributes and the sentry environment data
+// resource attributes
+func otelRecord(ev *sentry.Event) map[string]interface{} {
+
+	attributes := make(map[string]interface{})
+	resource := make(map[string]interface{})
+
+	for name, fields := range ev.Contexts {
+		m, ok := fields.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch name {
+		case "os":
+			copyAttribute(resource, "os.type", m["name"])
+			copyAttribute(resource, "os.version", m["version"])
+		case "device":
+			copyAttribute(resource, "host.arch", m["arch"])
+		case "runtime":
+			copyAttribute(resource, "process.runtime.name", m["name"])
+			copyAttribute(resource, "process.runtime.version", m["version"])
+		case "Default Context":
+			for k, v := range m {
+				attributes[k] = v
+			}
+		default:
+			for k, v := range m {
+				attributes[name+"."+k] = v
+			}
+		}
+	}
+
+	for k, v := range ev.Tags {
+		attributes[k] = v
+	}
+
+	if len(ev.Exception) > 0 {
+		ex := ev.Exception[len(ev.Exception)-1]
+		attributes["exception.type"] = ex.Type
+		attributes["exception.message"] = ex.Value
+		if ev.Exception[0].Stacktrace != nil {
+			attributes["exception.stacktrace"] = stackTraceString(ev.Exception[0].Stacktrace)
+		}
+	}
+
+	copyAttribute(resource, "host.name", ev.ServerName)
+	copyAttribute(resource, "deployment.environment", ev.Environment)
+	copyAttribute(resource, "service.version", ev.Release)
+
+	record := map[string]interface{}{
+		"timestamp":       ev.Timestamp.UTC().Format(time.RFC3339Nano),
+		"severity_text":   strings.ToUpper(levelName(ev.Level)),
+		"severity_number": otelSeverity[ev.Level],
+		"body":            ev.Message,
+	}
+	if len(attributes) > 0 {
+		record["attributes"] = attributes
+	}
+	if len(resource) > 0 {
+		record["resource"] = resource
+	}
+
+	return record
+}
+
+func copyAttribute(m map[string]interface{}, key string, v interface{}) {
+	if v != nil && v != "" {
+		m[key] = v
+	}
+}
+
+// flat JSON object of an event: time, level, msg, caller, error fields, the
+// default context's fields and named contexts as nested objects
+func jsonObject(ev *sentry.Event) map[string]interface{} {
+
+	obj := make(map[string]interface{})
+
+	for name, fields := range ev.Contexts {
+		if sentryContext(name) {
+			continue
+		}
+		if name == "Default Context" {
+			if m, ok := fields.(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+				continue
+			}
+		}
+		obj[name] = fields
+	}
+
+	// written last, fields can't overwrite them
+	obj["time"] = ev.Timestamp.UTC().Format(time.RFC3339Nano)
+	obj["level"] = levelName(ev.Level)
+	obj["msg"] = ev.Message
+
+	if c, ok := ev.Extra["caller"]; ok {
+		obj["caller"] = c
+	}
+
+	if len(ev.Exception) > 0 {
+		ex := ev.Exception[len(ev.Exception)-1]
+		obj["error"] = ex.Value
+		obj["error_type"] = ex.Type
+		if ev.Exception[0].Stacktrace != nil {
+			obj["stacktrace"] = stackTraceString(ev.Exception[0].Stacktrace)
+		}
+	}
+
+	return obj
+}
+
+// Cloud Logging severities of sentry levels
+var cloudSeverity = map[sentry.Level]string{
+	sentry.LevelDebug:   "DEBUG",
+	sentry.LevelInfo:    "INFO",
+	sentry.LevelWarning: "WARNING",
+	sentry.LevelError:   "ERROR",
+	sentry.LevelFatal:   "CRITICAL",
+}
+
+// structured log entry Cloud Logging reads from stdout: the JSON object of the
+// event with its special fields severity, message, timestamp and trace, the
+// trace qualified by the project in GOOGLE_CLOUD_PROJECT if set
+func cloudLoggingEntry(ev *sentry.Event) map[string]interface{} {
+
+	entry := jsonObject(ev)
+	delete(entry, "time")
+	delete(entry, "level")
+	delete(entry, "msg")
+
+	entry["severity"] = cloudSeverity[ev.Level]
+	entry["message"] = ev.Message
+	entry["timestamp"] = ev.Timestamp.UTC().Format(time.RFC3339Nano)
+
+	if id := traceID(ev); id != "" {
+		if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+			id = "projects/" + project + "/traces/" + id
+		}
+		entry["logging.googleapis.com/trace"] = id
+	}
+
+	return entry
+}
+
+// JSON log record of the Lambda log format: timestamp, level, message and the
+// fields of the event, traceId from the event or the X-Ray trace header
+func lambdaRecord(ev *sentry.Event) map[string]interface{} {
+
+	record := jsonObject(ev)
+	delete(record, "time")
+	delete(record, "msg")
+
+	record["timestamp"] = ev.Timestamp.UTC().Format(time.RFC3339Nano)
+	record["level"] = strings.ToUpper(levelName(ev.Level))
+	record["message"] = ev.Message
+
+	if id := traceID(ev); id != "" {
+		record["traceId"] = id
+	} else if id := os.Getenv("_X_AMZN_TRACE_ID"); id != "" {
+		record["traceId"] = id
+	}
+
+	return record
+}
+
+// trace ID of an event: trace_id of sentry's trace context or of the fields
+func traceID(ev *sentry.Event) string {
+
+	for _, name := range []string{"trace", "Default Context"} {
+		if m, ok := ev.Contexts[name].(map[string]interface{}); ok {
+			if id, ok := m["trace_id"].(string); ok && id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
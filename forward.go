@@ -0,0 +1,71 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// CaptureSentryEvent sends an event produced by sentry-go, e.g. by sentryhttp
+// or another SDK user, to all destinations, see (*Context).CaptureSentryEvent
+func CaptureSentryEvent(ev *sentry.Event) {
+	newContext().CaptureSentryEvent(ev)
+}
+
+// CaptureSentryEvent sends an event produced by sentry-go to the destinations
+// of x. Destination levels apply, the event is not changed. Events already
+// sent to Sentry by sentry-go can be kept from senlog's Sentry destination:
+//
+//	sentry.Init(sentry.ClientOptions{
+//		BeforeSend: func(ev *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+//			senlog.Except("sentry").CaptureSentryEvent(ev)
+//			return ev
+//		},
+//	})
+func (x *Context) CaptureSentryEvent(ev *sentry.Event) {
+
+	if x == nil || ev == nil {
+		return
+	}
+
+	if atomic.LoadInt32(&shutdown) == 1 { // Shutdown called, no new events
+		reportDrop("", DroppedByShutdown, senlogLevels[ev.Level], ev.Message)
+		return
+	}
+
+	ev = copyEvent(ev)
+
+	if ev.EventID == "" {
+		ev.EventID = newEventID()
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = now()
+	}
+	if _, ok := senlogLevels[ev.Level]; !ok {
+		if len(ev.Exception) > 0 {
+			ev.Level = sentry.LevelError
+		} else {
+			ev.Level = sentry.LevelInfo
+		}
+	}
+
+	if !Enabled(senlogLevels[ev.Level]) { // no destination would log it
+		reportDrop("", DroppedByLevel, senlogLevels[ev.Level], ev.Message)
+		return
+	}
+
+	r := sentryRecord(ev)
+	countSLIs(r)
+	broadcast(context.Background(), x, r)
+}
@@ -2,6 +2,14 @@ module github.com/ejazmughal/senlog
 
 go 1.18
 
-require github.com/getsentry/sentry-go v0.13.0
+require (
+	github.com/getsentry/sentry-go v0.13.0
+	github.com/redis/go-redis/v9 v9.0.5
+	github.com/robfig/cron/v3 v3.0.1
+)
 
-require golang.org/x/sys v0.0.0-20211007075335-d3039528d8ac // indirect
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/sys v0.0.0-20211007075335-d3039528d8ac // indirect
+)
@@ -0,0 +1,115 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// transport keeping the events it receives
+type recordingTransport struct {
+	Logger
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func newRecordingTransport(minLevel Level) *recordingTransport {
+	t := new(recordingTransport)
+	t.SetLogLevel(minLevel)
+	return t
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions) {}
+func (t *recordingTransport) Flush(time.Duration) bool       { return true }
+
+func (t *recordingTransport) SendEvent(ev *sentry.Event) {
+	t.Call(func(ev *sentry.Event) {
+		t.mu.Lock()
+		t.events = append(t.events, ev)
+		t.mu.Unlock()
+	}, ev)
+}
+
+func (t *recordingTransport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// messages of the events sent, setup notices excluded
+func (t *recordingTransport) Messages() []string {
+	var msgs []string
+	for _, ev := range t.Events() {
+		if ev.Logger == loggerName && ev.Contexts["Default Context"] != nil {
+			if _, setup := ev.Contexts["Default Context"].(map[string]interface{})["destination"]; setup {
+				continue
+			}
+		}
+		msgs = append(msgs, ev.Message)
+	}
+	return msgs
+}
+
+// transport whose sends block until the test ends once it is hung, a hung
+// backend
+type hungTransport struct {
+	Logger
+	hung    int32
+	release chan struct{}
+}
+
+func newHungTransport(t *testing.T) *hungTransport {
+	h := &hungTransport{release: make(chan struct{})}
+	t.Cleanup(func() { close(h.release) })
+	return h
+}
+
+func (t *hungTransport) Configure(sentry.ClientOptions) {}
+func (t *hungTransport) Flush(time.Duration) bool       { return true }
+func (t *hungTransport) hang()                          { atomic.StoreInt32(&t.hung, 1) }
+
+func (t *hungTransport) SendEvent(*sentry.Event) {
+	if atomic.LoadInt32(&t.hung) == 1 {
+		<-t.release
+	}
+}
+
+// adds a destination for the test, removed with the console muted meanwhile
+func addTestDestination(t *testing.T, key string, options sentry.ClientOptions) {
+
+	t.Helper()
+	if err := AddDestination(key, options); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RemoveDestination(key) })
+}
+
+// keeps the console and diagnostics quiet during the test
+func quiet(t *testing.T) {
+
+	t.Helper()
+	SetDiagnostics(func(error) {})
+	SetLogLevel("console", FATAL+1)
+	t.Cleanup(func() {
+		SetDiagnostics(nil)
+		SetLogLevel("console", DEBUG)
+	})
+}
+
+// error type whose nil pointer is a non-nil error value
+type typedNilError struct{}
+
+func (*typedNilError) Error() string { return "typed nil" }
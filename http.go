@@ -0,0 +1,223 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// request headers never logged by senlog http helpers
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Auth-Token":        true,
+}
+
+// RecoveryHandler recovers panics of next, logs them as FATAL events with the
+// sanitized request and the panicking goroutine's stack, and responds with 500.
+// Unlike FTL the process keeps running. http.ErrAbortHandler is passed on.
+func RecoveryHandler(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		sw := &statusWriter{ResponseWriter: w}
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler { // net/http's way to abort silently
+				panic(rec)
+			}
+
+			if !sw.wroteHeader {
+				sw.WriteHeader(http.StatusInternalServerError)
+			}
+
+			err, ok := rec.(error)
+			if !ok {
+				err = errors.New(fmt.Sprint(rec))
+			}
+
+			x := Cxt("response").Set("status_code", sw.status)
+			captureWith(r.Context(), FATAL, err, x, "Recovered panic in HTTP handler", func(record *Record) {
+				record.sentryPart().Request = sanitizedRequest(r)
+				if len(record.Errors) > 0 { // none for a typed nil error
+					record.Errors[0].Type = "panic"
+				}
+			})
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// sentry request without cookies and credentials, the body is not read
+func sanitizedRequest(r *http.Request) *sentry.Request {
+
+	req := sentry.NewRequest(r)
+	req.Cookies = ""
+	for k := range req.Headers {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			req.Headers[k] = "[Filtered]"
+		}
+	}
+	return req
+}
+
+// response writer remembering the status code
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// RoundTripperOptions configures RoundTripper, the zero value logs successful
+// requests as DEBUG without headers and doesn't retry
+type RoundTripperOptions struct {
+	Level         Level    // level of successful requests, DEBUG if 0
+	LogHeaders    bool     // log request headers, credentials are filtered
+	RedactHeaders []string // headers filtered in addition to credentials and cookies
+	MaxRetries    int      // retries of idempotent requests failing with a transport error
+}
+
+// RoundTripper logs outbound requests of an http.Client (method, URL, status,
+// duration, retries) and transport errors as ERR events. A nil base uses
+// http.DefaultTransport, nil opts the zero options.
+//
+//	client := &http.Client{Transport: senlog.RoundTripper(nil, nil)}
+func RoundTripper(base http.RoundTripper, opts *RoundTripperOptions) http.RoundTripper {
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts == nil {
+		opts = new(RoundTripperOptions)
+	}
+
+	redact := make(map[string]bool, len(sensitiveHeaders)+len(opts.RedactHeaders))
+	for k := range sensitiveHeaders {
+		redact[k] = true
+	}
+	for _, k := range opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(k)] = true
+	}
+
+	return &roundTripper{base: base, opts: *opts, redact: redact}
+}
+
+type roundTripper struct {
+	base   http.RoundTripper
+	opts   RoundTripperOptions
+	redact map[string]bool
+}
+
+func (rt *roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+
+	start := time.Now()
+
+	u := *r.URL
+	u.User = nil // no credentials in logs
+	x := Cxt("http.client").Set("method", r.Method).Set("url", u.String())
+
+	if rt.opts.LogHeaders {
+		headers := make(map[string]string, len(r.Header))
+		for k, v := range r.Header {
+			if rt.redact[http.CanonicalHeaderKey(k)] {
+				headers[k] = "[Filtered]"
+			} else {
+				headers[k] = strings.Join(v, ",")
+			}
+		}
+		x = x.Set("headers", headers)
+	}
+
+	var (
+		resp    *http.Response
+		err     error
+		retries int
+	)
+
+	for {
+		req := r
+		if retries > 0 {
+			req = r.Clone(r.Context())
+			if r.GetBody != nil {
+				if req.Body, err = r.GetBody(); err != nil {
+					break
+				}
+			}
+		}
+
+		resp, err = rt.base.RoundTrip(req)
+		if err == nil || retries >= rt.opts.MaxRetries || !retryable(r) || r.Context().Err() != nil {
+			break
+		}
+		retries++
+	}
+
+	x = x.Set("duration", time.Since(start).String()).Set("retries", retries)
+
+	if err != nil {
+		x.ERRCtx(r.Context(), err, "HTTP request failed")
+		return resp, err
+	}
+
+	x = x.Set("status", resp.StatusCode)
+
+	switch {
+	case resp.StatusCode >= 500:
+		x.WRNCtx(r.Context(), "HTTP request returned server error")
+	default:
+		level := rt.opts.Level
+		if level == 0 {
+			level = DEBUG
+		}
+		captureCtx(r.Context(), level, nil, x, "HTTP request")
+	}
+
+	return resp, nil
+}
+
+// idempotent request whose body can be sent again
+func retryable(r *http.Request) bool {
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return r.Body == nil || r.Body == http.NoBody || r.GetBody != nil
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestRecoveryHandlerTypedNilError(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	h := RecoveryHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		var e *typedNilError
+		panic(e)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", w.Code)
+	}
+	if msgs := rec.Messages(); len(msgs) != 1 {
+		t.Errorf("events %q, want the recovered panic", msgs)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "sync"
+
+// strings kept by each intern table, later strings are not interned
+const maxInterned = 10000
+
+// bounded table of strings derived from keys, e.g. interned or normalized names
+type internTable struct {
+	mu sync.RWMutex
+	m  map[string]string
+}
+
+// value of key, computed by derive and kept on first use
+func (t *internTable) lookup(key string, derive func(string) string) string {
+
+	t.mu.RLock()
+	v, ok := t.m[key]
+	full := len(t.m) >= maxInterned
+	t.mu.RUnlock()
+	if ok {
+		return v
+	}
+	if full { // no write lock on the hot path once the table is full
+		return derive(key)
+	}
+
+	key = copyString(key)
+	v = derive(key)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.m == nil {
+		t.m = make(map[string]string)
+	}
+	if prev, ok := t.m[key]; ok { // kept meanwhile
+		return prev
+	}
+	if len(t.m) < maxInterned {
+		t.m[key] = v
+	}
+	return v
+}
+
+var (
+	interned         internTable // field keys and context names, see Intern
+	internedMessages internTable // formatted messages, apart so their values can't crowd out keys
+)
+
+// Intern returns the canonical instance of s, so repeated field keys and
+// messages built at run time share one copy and compare by pointer first. Set
+// and Cxt intern keys and context names, messages formatted from several
+// values are interned too. At most 10000 strings are interned, others are
+// returned as is.
+func Intern(s string) string {
+	return interned.lookup(s, sameString)
+}
+
+func sameString(s string) string {
+	return s
+}
+
+// copy of s not sharing the memory of a larger string
+func copyString(s string) string {
+	return string(append([]byte(nil), s...))
+}
@@ -0,0 +1,118 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// events held by a destination, e.g. in a queue or a ring buffer
+type holdingTransport struct {
+	Logger
+	events []*sentry.Event
+}
+
+func (t *holdingTransport) Configure(sentry.ClientOptions) {}
+func (t *holdingTransport) Flush(time.Duration) bool       { return true }
+func (t *holdingTransport) SendEvent(ev *sentry.Event)     { t.events = append(t.events, ev) }
+
+func benchDestination(b *testing.B) *holdingTransport {
+
+	SetLogLevel("console", FATAL+1)
+	b.Cleanup(func() { SetLogLevel("console", DEBUG) })
+	hold := new(holdingTransport)
+	if err := AddDestination("hold", sentry.ClientOptions{Transport: hold}); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { RemoveDestination("hold") })
+	hold.events = make([]*sentry.Event, 0, b.N+1)
+	return hold
+}
+
+// heap in use after a GC
+func heapInUse() uint64 {
+
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapInuse
+}
+
+// a template message and fields of literal keys
+func BenchmarkLogLiteral(b *testing.B) {
+
+	benchDestination(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Set("user", i).INF("Logged in")
+	}
+}
+
+// keys and messages built at run time, repeating, e.g. from a table of metric
+// names; retained-B/op is the memory kept by the events held
+func BenchmarkLogBuiltStrings(b *testing.B) {
+
+	hold := benchDestination(b)
+	names := []string{"cpu", "memory", "disk", "network"}
+	before := heapInUse()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		name := names[i%len(names)]
+		Set(name+"_usage_percent", i).INF("Metric ", name, " over threshold")
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(heapInUse()-before)/float64(b.N), "retained-B/op")
+	runtime.KeepAlive(hold)
+}
+
+// address of the bytes of s
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestInternReturnsOneInstance(t *testing.T) {
+
+	interned.mu.Lock()
+	interned.m = nil // filled by other tests
+	interned.mu.Unlock()
+
+	a := Intern(strconv.Itoa(12345) + "_key")
+	b := Intern(strconv.Itoa(12345) + "_key")
+	if a != "12345_key" || stringData(a) != stringData(b) {
+		t.Error("interned strings don't share memory")
+	}
+
+	x := Set(strconv.Itoa(6789)+"_key", 1).Set(strconv.Itoa(6789)+"_key", 2)
+	keys := x.fields[len(x.fields)-2:]
+	if keys[0].key != keys[1].key || stringData(keys[0].key) != stringData(keys[1].key) {
+		t.Error("keys set at run time don't share memory")
+	}
+}
+
+func TestMessageOfOneStringIsNotCopied(t *testing.T) {
+
+	s := strconv.Itoa(42) + " done"
+	if m := message([]interface{}{s}); stringData(m) != stringData(s) {
+		t.Error("message of one string was copied")
+	}
+	if m := message([]interface{}{"took ", 3, "s"}); m != "took 3s" {
+		t.Errorf("message %q, want fmt.Sprint's", m)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Job runs fn as a job named name, see JobAttempt
+func Job(name string, fn func(*Context) error) error {
+	return JobAttempt(name, 1, fn)
+}
+
+// JobAttempt runs fn with a "job" context (name, start, attempt) and logs its
+// start, finish and failure with the duration. A panic in fn is logged as an
+// error and returned. attempt is the caller's delivery/retry count, e.g. the
+// redelivery count of a queue message.
+func JobAttempt(name string, attempt int, fn func(*Context) error) (err error) {
+
+	start := now()
+	x := Cxt("job").Set("name", name).Set("start", start.Format(time.RFC3339)).Set("attempt", attempt)
+
+	x.DBG("Job started")
+
+	defer func() {
+		rec := recover()
+
+		x := x.Set("duration", now().Sub(start).String())
+
+		if rec != nil {
+			e, ok := rec.(error)
+			if !ok {
+				e = errors.New(fmt.Sprint(rec))
+			}
+			err = fmt.Errorf("job %s panicked: %w", name, e)
+
+			captureWith(context.Background(), ERROR, e, x, "Job panicked", func(r *Record) {
+				if len(r.Errors) > 0 { // none for a typed nil error
+					r.Errors[0].Type = "panic"
+				}
+			})
+			return
+		}
+
+		if err != nil {
+			x.ERR(err, "Job failed")
+			return
+		}
+
+		x.INF("Job finished")
+	}()
+
+	return fn(x)
+}
@@ -0,0 +1,36 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestJobPanicTypedNilError(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(ERROR)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	err := Job("typed-nil", func(*Context) error {
+		var e *typedNilError
+		panic(e)
+	})
+
+	if err == nil {
+		t.Error("Job returned no error for a panic")
+	}
+	if msgs := rec.Messages(); len(msgs) != 1 {
+		t.Errorf("events %q, want the panic", msgs)
+	}
+}
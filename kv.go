@@ -0,0 +1,69 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+// key of values without a string key, like slog's
+const badKey = "!BADKEY"
+
+// SetKV sets alternating keys and values, a shorthand of a Set chain:
+//
+//	x.SetKV("user", id, "attempt", n) // x.Set("user", id).Set("attempt", n)
+//
+// A value without a string key before it is set as "!BADKEY".
+func (x *Context) SetKV(kv ...interface{}) *Context {
+
+	for len(kv) > 0 && x != nil {
+		k, ok := kv[0].(string)
+		if !ok || len(kv) == 1 {
+			x = x.Set(badKey, kv[0])
+			kv = kv[1:]
+			continue
+		}
+		x = x.Set(k, kv[1])
+		kv = kv[2:]
+	}
+	return x
+}
+
+// kv variants log msg with the fields of alternating keys and values, see SetKV:
+//
+//	senlog.INFkv("User logged in", "user", id, "method", "oauth")
+
+func DBGkv(msg string, kv ...interface{}) {
+	if !debugBuilt || !Enabled(DEBUG) {
+		return
+	}
+	capture(DEBUG, nil, newContext().SetKV(kv...), msg)
+}
+
+func INFkv(msg string, kv ...interface{}) {
+	if !Enabled(INFO) {
+		return
+	}
+	capture(INFO, nil, newContext().SetKV(kv...), msg)
+}
+
+func WRNkv(msg string, kv ...interface{}) {
+	if !Enabled(WARN) {
+		return
+	}
+	capture(WARN, nil, newContext().SetKV(kv...), msg)
+}
+
+func ERRkv(e error, msg string, kv ...interface{}) {
+	checkNilError(e)
+	capture(ERROR, e, newContext().SetKV(kv...), msg)
+}
+
+func FTLkv(e error, msg string, kv ...interface{}) {
+	checkNilError(e)
+	fatal(capture(FATAL, e, newContext().SetKV(kv...), msg))
+}
@@ -0,0 +1,92 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is the severity of an event, DEBUG to FATAL. The zero value means not
+// set where a default applies, e.g. TeeOutput.MinLevel.
+//
+// Functions taking a level accept the constants as before, levels held in int
+// variables need a conversion, e.g. senlog.Level(lvl). Transports implementing
+// LeveledLogger use Level, code that can't change yet can use the compat
+// package.
+type Level int
+
+// log levels, untyped so they still fit int variables: Level(WARN).String()
+const (
+	DEBUG = 1
+	INFO  = 2
+	WARN  = 3
+	ERROR = 4
+	FATAL = 5
+)
+
+var levelNames = [...]string{"", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (l Level) valid() bool {
+	return l >= DEBUG && l <= FATAL
+}
+
+// String returns the name of the level, e.g. WARN
+func (l Level) String() string {
+
+	if l < 0 || int(l) >= len(levelNames) {
+		return fmt.Sprintf("Level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// MarshalText returns the name of the level, empty for the zero value.
+// FATAL+1, logging nothing, has no name and is written as its number.
+func (l Level) MarshalText() ([]byte, error) {
+
+	if l == FATAL+1 {
+		return strconv.AppendInt(nil, FATAL+1, 10), nil
+	}
+	if l != 0 && !l.valid() {
+		return nil, fmt.Errorf("senlog: invalid level %d", int(l))
+	}
+	return []byte(levelNames[l]), nil
+}
+
+// UnmarshalText reads a level name in any case, WARNING for WARN, or a number
+func (l *Level) UnmarshalText(text []byte) error {
+
+	s := strings.ToUpper(strings.TrimSpace(string(text)))
+	if s == "WARNING" {
+		s = "WARN"
+	}
+	for i, name := range levelNames {
+		if s == name {
+			*l = Level(i)
+			return nil
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n != 0 && n != FATAL+1 && !Level(n).valid() {
+		return fmt.Errorf("senlog: invalid level %q", text)
+	}
+	*l = Level(n)
+	return nil
+}
+
+// UnmarshalJSON reads a level name or, as written before levels had names, a
+// number
+func (l *Level) UnmarshalJSON(b []byte) error {
+	return l.UnmarshalText(bytes.Trim(b, `"`))
+}
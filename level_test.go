@@ -0,0 +1,41 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLevelConstantsStayUntyped(t *testing.T) {
+
+	var i int = ERROR // compiled before levels had a type
+	var l Level = ERROR
+
+	if Level(i) != l || l.String() != "ERROR" {
+		t.Errorf("ERROR is %d, %s", i, l)
+	}
+}
+
+func TestLevelJSON(t *testing.T) {
+
+	var c struct{ Level Level }
+	for _, in := range []string{`{"Level":"warn"}`, `{"Level":"WARNING"}`, `{"Level":3}`} {
+		if err := json.Unmarshal([]byte(in), &c); err != nil || c.Level != WARN {
+			t.Errorf("%s: got %s, %v", in, c.Level, err)
+		}
+	}
+
+	b, _ := json.Marshal(c)
+	if string(b) != `{"Level":"WARN"}` {
+		t.Errorf("marshaled %s", b)
+	}
+}
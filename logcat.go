@@ -0,0 +1,134 @@
+//go:build linux
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// socket of the Android log daemon, written to by liblog too
+const logdSocket = "/dev/socket/logdw"
+
+// bytes of tag and message logd keeps of an entry, longer lines are split
+const logdMaxPayload = 4068
+
+// bytes of a tag kept, longer tags are cut so messages keep most of the payload
+const logdMaxTag = 128
+
+// LogcatTransport writes events to Android logcat under a tag, with the
+// priority of their level, one logcat entry per line of text. Tags are cut to
+// 128 bytes. It writes to the
+// socket of the log daemon directly, so it needs neither cgo nor liblog. It is
+// the default console destination of Android builds, where stdout is discarded.
+type LogcatTransport struct {
+	Logger
+	tag  string
+	conn *net.UnixConn
+}
+
+// NewLogcatTransport returns a transport writing to logcat, or the error of
+// connecting to the log daemon
+func NewLogcatTransport(tag string, minLogLevel Level) (*LogcatTransport, error) {
+	return newLogcatTransport(logdSocket, tag, minLogLevel)
+}
+
+func newLogcatTransport(socket string, tag string, minLogLevel Level) (*LogcatTransport, error) {
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tag) > logdMaxTag {
+		tag = tag[:logdMaxTag]
+	}
+	t := &LogcatTransport{tag: tag, conn: conn}
+	t.SetLogLevel(minLogLevel)
+	return t, nil
+}
+
+// android_LogPriority of the levels
+var logcatPriorities = map[sentry.Level]byte{
+	sentry.LevelDebug:   3,
+	sentry.LevelInfo:    4,
+	sentry.LevelWarning: 5,
+	sentry.LevelError:   6,
+	sentry.LevelFatal:   7,
+}
+
+func (t *LogcatTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *LogcatTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		priority, ok := logcatPriorities[ev.Level]
+		if !ok {
+			priority = logcatPriorities[sentry.LevelInfo]
+		}
+
+		ts := ev.Timestamp
+		if ts.IsZero() {
+			ts = now()
+		}
+
+		text := strings.TrimSuffix(formatEvent(ev, nil, new(out)), "\n")
+		max := logdMaxPayload - len(t.tag) - 3 // priority and two NULs
+		for _, line := range strings.Split(text, "\n") {
+			for {
+				chunk := line
+				if len(chunk) > max {
+					chunk = chunk[:max]
+				}
+				if _, err := t.conn.Write(logdEntry(priority, t.tag, chunk, ts)); err != nil {
+					diagnose(fmt.Errorf("could not write event %s to logcat: %w", ev.EventID, err))
+					return
+				}
+				if line = line[len(chunk):]; line == "" {
+					break
+				}
+			}
+		}
+	}, ev)
+}
+
+// datagram of a logd entry: the packed android_log_header_t of liblog with the
+// main log buffer, then priority, tag and message, both NUL terminated
+func logdEntry(priority byte, tag string, msg string, ts time.Time) []byte {
+
+	b := make([]byte, 11, 11+1+len(tag)+1+len(msg)+1)
+	b[0] = 0 // LOG_ID_MAIN
+	binary.LittleEndian.PutUint16(b[1:], uint16(syscall.Gettid()))
+	binary.LittleEndian.PutUint32(b[3:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(b[7:], uint32(ts.Nanosecond()))
+
+	b = append(b, priority)
+	b = append(append(b, tag...), 0)
+	return append(append(b, msg...), 0)
+}
+
+func (t *LogcatTransport) Flush(time.Duration) bool {
+	return true
+}
+
+// Close closes the connection to the log daemon
+func (t *LogcatTransport) Close() error {
+	return t.conn.Close()
+}
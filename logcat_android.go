@@ -0,0 +1,29 @@
+//go:build android
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"os"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// logcat, or stderr if the log daemon can't be reached
+func defaultConsoleTransport() sentry.Transport {
+
+	t, err := NewLogcatTransport("senlog", DEBUG)
+	if err != nil {
+		return NewIoTransport(os.Stderr, os.Stderr, DEBUG)
+	}
+	return t
+}
@@ -0,0 +1,155 @@
+//go:build linux
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// logd stand-in, returns the datagrams received
+func logdServer(t *testing.T) (socket string, read func() [][]byte) {
+
+	socket = filepath.Join(t.TempDir(), "logdw")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return socket, func() (entries [][]byte) {
+		buf := make([]byte, 1<<16)
+		for {
+			conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, err := conn.Read(buf)
+			if err != nil {
+				return entries
+			}
+			entries = append(entries, append([]byte(nil), buf[:n]...))
+		}
+	}
+}
+
+func TestLogcatTransportWritesLogdEntries(t *testing.T) {
+
+	socket, read := logdServer(t)
+	tr, err := newLogcatTransport(socket, "app", DEBUG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	tr.SendEvent(&sentry.Event{Level: sentry.LevelError, Message: "first\nsecond"})
+
+	entries := read()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want one per line", len(entries))
+	}
+	e := entries[0]
+	if e[0] != 0 || e[11] != 6 {
+		t.Errorf("log id %d, priority %d, want main and ERROR (6)", e[0], e[11])
+	}
+	payload := bytes.Split(e[12:], []byte{0})
+	if string(payload[0]) != "app" || !strings.HasSuffix(string(payload[1]), "first") {
+		t.Errorf("tag %q, message %q", payload[0], payload[1])
+	}
+}
+
+func TestLogcatTransportSplitsLongLines(t *testing.T) {
+
+	socket, read := logdServer(t)
+	tr, err := newLogcatTransport(socket, "app", DEBUG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	tr.SendEvent(&sentry.Event{Level: sentry.LevelInfo, Message: strings.Repeat("x", 2*logdMaxPayload)})
+
+	entries := read()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want the line split in 3", len(entries))
+	}
+	for _, e := range entries {
+		if len(e)-11 > logdMaxPayload {
+			t.Errorf("payload of %d bytes, logd keeps %d", len(e)-11, logdMaxPayload)
+		}
+	}
+}
+
+func TestLogcatTransportCutsLongTags(t *testing.T) {
+
+	socket, read := logdServer(t)
+	tr, err := newLogcatTransport(socket, strings.Repeat("t", 2*logdMaxPayload), DEBUG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	tr.SendEvent(&sentry.Event{Level: sentry.LevelInfo, Message: "long tag"}) // must not panic
+
+	entries := read()
+	if len(entries) != 1 || len(bytes.Split(entries[0][12:], []byte{0})[0]) != logdMaxTag {
+		t.Errorf("got %d entries, want one with the tag cut to %d bytes", len(entries), logdMaxTag)
+	}
+}
+
+func TestLogcatTransportUsesEventTime(t *testing.T) {
+
+	socket, read := logdServer(t)
+	tr, err := newLogcatTransport(socket, "app", DEBUG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+
+	ts := time.Date(2022, 5, 1, 12, 0, 0, 42, time.UTC)
+	tr.SendEvent(&sentry.Event{Level: sentry.LevelInfo, Message: "then", Timestamp: ts})
+
+	entries := read()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	sec, nsec := binary.LittleEndian.Uint32(entries[0][3:]), binary.LittleEndian.Uint32(entries[0][7:])
+	if int64(sec) != ts.Unix() || int(nsec) != ts.Nanosecond() {
+		t.Errorf("entry time %d.%09d, want %d.%09d", sec, nsec, ts.Unix(), ts.Nanosecond())
+	}
+}
+
+func TestLogcatTransportDiagnosesWriteErrors(t *testing.T) {
+
+	var diagnosed int32
+	SetDiagnostics(func(error) { atomic.AddInt32(&diagnosed, 1) })
+	defer SetDiagnostics(nil)
+
+	socket, _ := logdServer(t)
+	tr, err := newLogcatTransport(socket, "app", DEBUG)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Close()
+
+	tr.SendEvent(&sentry.Event{Level: sentry.LevelInfo, Message: "first\nsecond"})
+
+	if n := atomic.LoadInt32(&diagnosed); n != 1 {
+		t.Errorf("%d diagnostics, want 1 for the failed write", n)
+	}
+}
@@ -12,6 +12,7 @@ package senlog
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,7 +20,10 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -27,14 +31,6 @@ import (
 
 const loggerName = "senlog"
 
-// log levels
-const (
-	DEBUG = 1
-	INFO  = 2
-	WARN  = 3
-	ERROR = 4
-	FATAL = 5
-)
 const FlushTimeout = 2 * time.Second
 
 // log levels (index) to sentry levels (value) maping
@@ -45,7 +41,7 @@ var sentryLevels = [5]sentry.Level{
 	sentry.LevelError,
 	sentry.LevelFatal}
 
-var senlogLevels = map[sentry.Level]int{
+var senlogLevels = map[sentry.Level]Level{
 	sentry.LevelDebug:   DEBUG,
 	sentry.LevelInfo:    INFO,
 	sentry.LevelWarning: WARN,
@@ -53,216 +49,617 @@ var senlogLevels = map[sentry.Level]int{
 	sentry.LevelFatal:   FATAL,
 }
 
-var hubs = make(map[string]*sentry.Hub)
+func AddDestination(key string, options sentry.ClientOptions) error {
 
-func init() {
+	var changes []Change
+	defer func() { auditChanges(changes...) }() // after registryMu is released
 
-	err := AddDestination("console", sentry.ClientOptions{
-		Dsn:       "",
-		Transport: NewIoTransport(os.Stdout, os.Stderr, DEBUG),
-	})
+	registryMu.Lock()
+	defer registryMu.Unlock()
 
+	_, exists := lookup(key)
+	if exists {
+		//Set("key", key).WRN("Destination key already exists")
+		return errors.New("Destination key already exists: " + key)
+	}
+
+	d, err := newDestination(key, options)
 	if err != nil {
-		fmt.Println(err, "Could not initiate log destination: console")
+		return err
 	}
-}
 
-func AddDestination(key string, options sentry.ClientOptions) error {
+	current := destinations()
+	updated := make([]*destination, len(current), len(current)+1)
+	copy(updated, current)
+	registry.Store(append(updated, d))
 
-	_, exists := hubs[key]
-	if exists {
-		//Set("key", key).WRN("Destination key already exists")
-		return errors.New("Destination key already exists: " + key)
+	if c, changed := recordChange(ChangedByAPI, "destination", key, "", "added"); changed {
+		changes = append(changes, c)
 	}
+	d.added(options)
+
+	return nil
+}
+
+func newDestination(key string, options sentry.ClientOptions) (*destination, error) {
 
 	hub := sentry.NewHub(nil, sentry.NewScope())
 
+	rate := options.SampleRate
+	options.SampleRate = 1 // sampled by the destination, priority events are exempt
+
 	client, err := sentry.NewClient(options)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	hub.BindClient(client)
 
-	hubs[key] = hub
+	d := &destination{key: key, hub: hub, sink: directSink(options), stop: make(chan struct{})}
+	d.setSampleRate(rate)
+	d.watchResults()
+	return d, nil
+}
+
+func (d *destination) added(options sentry.ClientOptions) {
 
 	//Set("destination", key).INF("Log destination added")
 	if options.Dsn == "" { // sentry DSN exists
-		Set("destination", key).WRN("\033[5m!\033[0m Sentry client initialized with empty DSN. No events will be delivered to sentry.")
+		d.notify(WARN, Set("destination", d.key), "Sentry client initialized with empty DSN. No events will be delivered to sentry.")
 	} else {
-		Set("destination", key).INF("Sentry client initialized with DSN. Events will be delivered to sentry.")
+		d.notify(INFO, Set("destination", d.key), "Sentry client initialized with DSN. Events will be delivered to sentry.")
 	}
-
-	return nil
 }
 
 func RemoveDestination(key string) {
 
-	_, exists := hubs[key]
+	var changes []Change
+	defer func() { auditChanges(changes...) }() // after registryMu is released
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	removed, exists := lookup(key)
 	if !exists { // destination doesn't exist
-		Set("destination", key).WRN("Log destination to remove doesn't exist")
+		notice("log destination to remove %q doesn't exist", key)
 	} else { // destination exists
-		Set("destination", key).INF("About to remove log destination, no events will be delivered")
-		delete(hubs, key)
+		notice("removing log destination %q, no events will be delivered", key)
 
+		current := destinations()
+		updated := make([]*destination, 0, len(current)-1)
+		for _, d := range current {
+			if d.key != key {
+				updated = append(updated, d)
+			}
+		}
+		registry.Store(updated)
+		close(removed.stop)
+
+		if c, changed := recordChange(ChangedByAPI, "destination", key, "added", "removed"); changed {
+			changes = append(changes, c)
+		}
 	}
 }
 
 // set min log level for a destinition
-func SetLogLevel(destinationKey string, minLevel int) {
+func SetLogLevel(destinationKey string, minLevel Level) {
 
-	_, exists := hubs[destinationKey]
+	d, exists := lookup(destinationKey)
 	if !exists { // destination doesn't exist
-		Set("destination", destinationKey).WRN("Cannot set log level, log destination doesn't exist.")
+		notice("cannot set log level, log destination %q doesn't exist", destinationKey)
 	} else { // destination exists
-		Set("destination", destinationKey).Set("LogLevel", minLevel).INF("Changing log level")
+		l, ok := d.hub.Client().Transport.(LeveledLogger)
+		if !ok {
+			notice("cannot set log level, transport of log destination %q has no level", destinationKey)
+			return
+		}
+		notice("changing log level of destination %q to %d", destinationKey, minLevel)
+
+		from := l.MinLogLevel()
+		l.SetLogLevel(minLevel)
 
-		tr := hubs[destinationKey].Client().Transport
-		tr.(LeveledLogger).SetLogLevel(minLevel)
+		if c, changed := recordChange(ChangedByAPI, "level", destinationKey, from.String(), minLevel.String()); changed {
+			auditChanges(c)
+		}
 	}
 }
 
+// Context holds the fields of an event. It is immutable: Set, Cxt and the other
+// methods return a new Context sharing the unchanged fields, so a base Context
+// can be stored and used by several goroutines.
 type Context struct {
-	current  string
-	contexts map[string]interface{}
+	current string
+	base    map[string]interface{} // contexts merged by Merge, never written
+	fields  []contextField         // set since, in order, see add
+	tail    *int32                 // length of the fields' backing array in use
+	except  []string               // destination keys that must not receive the event
 }
 
-func Cxt(k string) *Context {
-	x := new(Context)
-	x.current = k
-	x.contexts = make(map[string]interface{})
-	x.contexts[k] = make(map[string]interface{})
+// a field set on a Context, or a context opened by Cxt if key is empty
+type contextField struct {
+	context string
+	key     string
+	kind    fieldKind   // where the value is stored, see typed.go
+	value   interface{} // values set by Set
+	str     string
+	num     uint64 // bits of int64, float64 and bool values
+}
 
-	return x
+func Cxt(k string) *Context {
+	k = Intern(k)
+	return (&Context{current: k}).add(contextField{context: k})
 }
 
+// methods of a nil (nop) Context do nothing, see At()
+
+// Cxt opens the context k, later fields are set in it. Opening a context set
+// before starts it anew, its earlier fields are not logged.
 func (x *Context) Cxt(k string) *Context {
-	x.current = k
-	x.contexts[k] = make(map[string]interface{})
+	if x == nil {
+		return nil
+	}
+	k = Intern(k)
+	c := x.add(contextField{context: k})
+	c.current = k
 
-	return x
+	return c
 }
 
 func (x *Context) Set(k string, v interface{}) *Context {
+	return x.set(contextField{key: k, value: v})
+}
 
-	x.contexts[x.current].(map[string]interface{})[k] = v
+// x with f set in the current context
+func (x *Context) set(f contextField) *Context {
 
-	return x
+	if x == nil {
+		return nil
+	}
+
+	if x.current == "" { // created by Except(), no context yet
+		c := x.derive()
+		c.current = "Default Context"
+		x = c
+	}
+	f.context = x.current
+	f.key = Intern(f.key)
+	return x.add(f)
 }
 
 func (x *Context) DBG(v ...interface{}) {
-	capture(DEBUG, nil, x, fmt.Sprint(v...))
+	if x == nil || !debugBuilt {
+		return
+	}
+	capture(DEBUG, nil, x, message(v))
 }
 
 func (x *Context) INF(v ...interface{}) {
-	capture(INFO, nil, x, fmt.Sprint(v...))
+	if x == nil {
+		return
+	}
+	capture(INFO, nil, x, message(v))
 }
 
 func (x *Context) WRN(v ...interface{}) {
-	capture(WARN, nil, x, fmt.Sprint(v...))
+	if x == nil {
+		return
+	}
+	capture(WARN, nil, x, message(v))
 }
 
 func (x *Context) ERR(e error, v ...interface{}) {
-	capture(ERROR, e, x, fmt.Sprint(v...))
+	if x == nil {
+		return
+	}
+	checkNilError(e)
+	capture(ERROR, e, x, message(v))
 }
 
-func (x *Context) FTL(e error, v ...interface{}) {
-	capture(FATAL, e, x, fmt.Sprint(v...))
+func (x *Context) DBGCtx(ctx context.Context, v ...interface{}) {
+	if x == nil || !debugBuilt {
+		return
+	}
+	captureCtx(ctx, DEBUG, nil, x, message(v))
+}
 
-	sentry.Flush(FlushTimeout)
-	os.Exit(1)
+func (x *Context) INFCtx(ctx context.Context, v ...interface{}) {
+	if x == nil {
+		return
+	}
+	captureCtx(ctx, INFO, nil, x, message(v))
+}
+
+func (x *Context) WRNCtx(ctx context.Context, v ...interface{}) {
+	if x == nil {
+		return
+	}
+	captureCtx(ctx, WARN, nil, x, message(v))
+}
+
+func (x *Context) ERRCtx(ctx context.Context, e error, v ...interface{}) {
+	if x == nil {
+		return
+	}
+	checkNilError(e)
+	captureCtx(ctx, ERROR, e, x, message(v))
+}
+
+func (x *Context) FTL(e error, v ...interface{}) {
+	if x == nil { // still exits
+		fatal(nil)
+	}
+	checkNilError(e)
+	fatal(capture(FATAL, e, x, message(v)))
 }
 
 func Set(k string, v interface{}) *Context {
-	x := Cxt("Default Context")
-	x.Set(k, v)
+	return Cxt("Default Context").Set(k, v)
+}
+
+// Except routes the event to all destinations but the given ones
+func Except(keys ...string) *Context {
+	x := newContext()
+	x.except = keys
 	return x
 }
 
+// At returns a nop context if no destination logs the given level, so a chain
+// like At(DEBUG).Set(...).Set(...).DBG(...) costs no allocations when disabled
+func At(level Level) *Context {
+	if !Enabled(level) {
+		return nil
+	}
+	return newContext()
+}
+
+// Enabled reports whether any destination logs the given level
+func Enabled(level Level) bool {
+
+	if level <= DEBUG && !debugBuilt {
+		return false
+	}
+
+	for _, d := range destinations() {
+		if atomic.LoadInt32(&d.inactive) == 1 || d.primary() != "" {
+			continue
+		}
+		l, ok := d.hub.Client().Transport.(LeveledLogger)
+		if !ok || level >= l.MinLogLevel() {
+			return true
+		}
+	}
+	return false
+}
+
+// context without any named context, Set() adds the default one
+func newContext() *Context {
+	return new(Context)
+}
+
+// copy of x to be changed, sharing its fields
+func (x *Context) derive() *Context {
+
+	c := *x
+	c.except = x.except[:len(x.except):len(x.except)] // append copies
+	return &c
+}
+
+// copy of x with f appended. Contexts derived from each other share the
+// backing array of their fields: f is written in place if no other Context
+// appended past the fields of x yet, else the fields are copied. Chained Sets
+// thus cost amortized constant time.
+func (x *Context) add(f contextField) *Context {
+
+	c := x.derive()
+	n := len(x.fields)
+	if n < cap(x.fields) && atomic.CompareAndSwapInt32(x.tail, int32(n), int32(n+1)) {
+		c.fields = append(x.fields, f)
+		return c
+	}
+
+	c.fields = make([]contextField, n+1, 2*n+4)
+	copy(c.fields, x.fields)
+	c.fields[n] = f
+	c.tail = new(int32)
+	*c.tail = int32(n + 1)
+	return c
+}
+
+// the contexts of x, a new map of new group maps each call. Fields set twice in
+// a context are handled as set by SetDuplicateKeys.
+func (x *Context) contexts() map[string]interface{} {
+
+	contexts := make(map[string]interface{}, len(x.base)+1)
+	for name, fields := range x.base {
+		contexts[name] = fields
+	}
+
+	owned := make(map[string]bool, 1) // groups copied from base or created
+	for _, f := range x.fields {
+		if f.key == "" { // opened by Cxt, replaces the group
+			contexts[f.context] = make(map[string]interface{})
+			owned[f.context] = true
+			continue
+		}
+
+		fields, _ := contexts[f.context].(map[string]interface{})
+		if !owned[f.context] {
+			copied := make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				copied[k] = v
+			}
+			fields = copied
+			contexts[f.context] = fields
+			owned[f.context] = true
+		}
+
+		k := f.key
+		if _, exists := fields[k]; exists {
+			k = duplicateKey(f.context, fields, k)
+		}
+		fields[k] = f.logged()
+	}
+	return contexts
+}
+
+// Except excludes the given destinations from receiving the event
+func (x *Context) Except(keys ...string) *Context {
+	if x == nil {
+		return nil
+	}
+	c := x.derive()
+	c.except = append(c.except, keys...)
+	return c
+}
+
+func (x *Context) excluded(key string) bool {
+	for _, k := range x.except {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
 // Multiple parameter values will be concated without spaces!
 func INF(v ...interface{}) {
-	capture(INFO, nil, nil, fmt.Sprint(v...)) // 1 = level info
+	capture(INFO, nil, nil, message(v)) // 1 = level info
 }
 
 func WRN(v ...interface{}) {
-	capture(WARN, nil, nil, fmt.Sprint(v...)) // 2 = level warn
+	capture(WARN, nil, nil, message(v)) // 2 = level warn
 }
 
 func DBG(v ...interface{}) {
-	capture(DEBUG, nil, nil, fmt.Sprint(v...))
+	if !debugBuilt {
+		return
+	}
+	capture(DEBUG, nil, nil, message(v))
 }
 
 func ERR(e error, v ...interface{}) {
-	capture(ERROR, e, nil, fmt.Sprint(v...))
+	checkNilError(e)
+	capture(ERROR, e, nil, message(v))
 }
 
 func FTL(e error, v ...interface{}) {
-	capture(FATAL, e, nil, fmt.Sprint(v...))
+	checkNilError(e)
+	fatal(capture(FATAL, e, nil, message(v)))
+}
+
+// Ctx variants stop waiting for a destination once ctx is done, the event
+// is then counted as dropped for that destination (see Dropped), unless it
+// reports the done ctx, see SetDeadlineFields. Fields carried by ctx (see
+// WithContext) are added to the event.
+
+func DBGCtx(ctx context.Context, v ...interface{}) {
+	if !debugBuilt {
+		return
+	}
+	captureCtx(ctx, DEBUG, nil, nil, message(v))
+}
+
+func INFCtx(ctx context.Context, v ...interface{}) {
+	captureCtx(ctx, INFO, nil, nil, message(v))
+}
 
-	sentry.Flush(FlushTimeout)
-	os.Exit(1)
+func WRNCtx(ctx context.Context, v ...interface{}) {
+	captureCtx(ctx, WARN, nil, nil, message(v))
 }
 
-func capture(level int, e error, x *Context, msg string) {
+func ERRCtx(ctx context.Context, e error, v ...interface{}) {
+	checkNilError(e)
+	captureCtx(ctx, ERROR, e, nil, message(v))
+}
+
+// number of events dropped by a destination
+func Dropped(destinationKey string) uint64 {
 
-	event := sentry.Event{
-		Timestamp: time.Now(),
-		Level:     sentryLevels[level-1],
-		Logger:    loggerName,
-		Message:   msg,
+	d, exists := lookup(destinationKey)
+	if !exists {
+		return 0
+	}
+	return atomic.LoadUint64(&d.dropped) + rateLimited(d)
+}
+
+// message of the arguments of a log call: one string, e.g. a template, as is,
+// others formatted by fmt.Sprint and interned
+func message(v []interface{}) string {
+
+	if len(v) == 1 {
+		if s, ok := v[0].(string); ok {
+			return s
+		}
+	}
+	return internedMessages.lookup(fmt.Sprint(v...), sameString)
+}
+
+func capture(level Level, e error, x *Context, msg string) *Record {
+	return captureCtx(context.Background(), level, e, x, msg)
+}
+
+func captureCtx(ctx context.Context, level Level, e error, x *Context, msg string) *Record {
+	return captureWith(ctx, level, e, x, msg, nil)
+}
+
+// builds the record, lets modify add to it and broadcasts it, returns nil if
+// the record was not sent
+func captureWith(ctx context.Context, level Level, e error, x *Context, msg string, modify func(*Record)) *Record {
+
+	if atomic.LoadInt32(&shutdown) == 1 { // Shutdown called, no new events
+		reportDrop("", DroppedByShutdown, level, msg)
+		return nil
+	}
+
+	sampled := debugSampled(ctx)
+	if !Enabled(level) && !sampled { // no destination would log it
+		reportDrop("", DroppedByLevel, level, msg)
+		return nil
+	}
+
+	x = withCarried(ctx, x)
+
+	r := newRecord(level, e, x, msg)
+
+	if sampled {
+		if r.Tags == nil {
+			r.Tags = make(map[string]string)
+		}
+		r.Tags[debugSampledTag] = "true"
+	}
+
+	if modify != nil {
+		modify(r)
+	}
+
+	applyTraceParent(ctx, r)
+	ctx = applyDeadline(ctx, r)
+	r.scope = sentryScope(ctx)
+
+	countSLIs(r)
+	broadcast(ctx, x, r)
+
+	if level >= ERROR {
+		sessionError()
+	}
+
+	return r
+}
+
+// record of a log call, not sent yet
+func newRecord(level Level, e error, x *Context, msg string) *Record {
+
+	r := &Record{
+		ID:     string(newEventID()), // same ID on all destinations
+		Time:   now(),
+		Level:  level,
+		Logger: loggerName,
+		Msg:    msg,
 	}
 
 	if x != nil {
-		event.Contexts = x.contexts
+		contexts := x.contexts()
+		checkSchemas(contexts)
+		r.setContexts(resolveValues(renameFields(contexts)))
 	}
 
-	st := sentry.NewStacktrace()
+	if loadSettings().reportCaller {
+		r.Caller = caller()
+	}
 
-	// drop senlog module frames
-	if st != nil {
-		threshold := len(st.Frames) - 1
-		for ; threshold > 0 && st.Frames[threshold].Module == "github.com/ejazmughal/senlog"; threshold-- {
+	if isNil(e) {
+		e = nil
+		if level >= ERROR {
+			r.Msg = nilErrorMessage(msg)
 		}
-		st.Frames = st.Frames[:threshold+1]
 	}
 
 	if e != nil {
-		event.Exception = append(event.Exception, sentry.Exception{
-			Value:      e.Error(),
-			Type:       reflect.TypeOf(e).String(),
-			Stacktrace: st,
-		})
+		logSite := stacktrace()
+		origin := errorStacktrace(e)
+
+		if origin == nil { // error carries no stack, the log call is the best we have
+			origin = logSite
+		} else if logSite != nil {
+			r.Stack = stackFrames(logSite)
+		}
+
+		r.Errors = []RecordError{{
+			Type:  reflect.TypeOf(e).String(),
+			Value: e.Error(),
+			Stack: stackFrames(origin),
+		}}
+	} else if level >= ERROR && attachStacktrace() {
+		r.Stack = stackFrames(stacktrace())
 	}
 
-	// broadcast event to all destinitions
-	for _, hub := range hubs {
+	return r
+}
+
+// send r to all destinitions
+func broadcast(ctx context.Context, x *Context, r *Record) {
+
+	sequence := sequenceKey(ctx)
+	var waits []func()
+
+	for _, d := range destinations() {
 
-		if hub != nil {
-			hub.CaptureEvent(&event)
+		if x != nil && x.excluded(d.key) || atomic.LoadInt32(&d.inactive) == 1 {
+			reportDrop(d.key, DroppedByRoute, r.Level, r.Msg)
+			continue
 		}
+
+		if d.primary() != "" { // shadows get the events of their primary
+			continue
+		}
+
+		sendShadows(d.key, r, sequence)
+
+		if !d.accepts(r) {
+			d.filter(r, DroppedByLevel)
+			continue
+		}
+
+		if wait := d.dispatch(ctx, r, sequence); wait != nil {
+			waits = append(waits, wait)
+		}
+	}
+
+	for _, wait := range waits { // full queues last, the others don't wait for them
+		wait()
 	}
 }
 
 type LeveledLogger interface {
-	SetLogLevel(minLevel int)
-	MinLogLevel() int
+	SetLogLevel(minLevel Level)
+	MinLogLevel() Level
 }
 
 type Logger struct {
-	minLevel int // Minimum severity level for logging
+	minLevel int32 // Minimum severity level for logging, changed while logging by SetLogLevel
+}
+
+func (l *Logger) SetLogLevel(level Level) {
+	atomic.StoreInt32(&l.minLevel, int32(level))
 }
 
-func (l *Logger) SetLogLevel(level int) {
-	l.minLevel = level
+func (l *Logger) MinLogLevel() Level {
+	return Level(atomic.LoadInt32(&l.minLevel))
 }
 
-func (l *Logger) MinLogLevel() int {
-	return l.minLevel
+// audit events and events of debug sampled requests are logged regardless of level
+func (l *Logger) logs(ev *sentry.Event) bool {
+	return senlogLevels[ev.Level] >= l.MinLogLevel() || levelExempt(ev)
+}
+
+// audit events and events of debug sampled requests, see SampleDebug
+func levelExempt(ev *sentry.Event) bool {
+	return ev.Logger == auditLoggerName || ev.Tags[debugSampledTag] == "true"
 }
 
 func (tr *Logger) Call(SendEventFunc func(*sentry.Event), ev *sentry.Event) {
 
-	if senlogLevels[ev.Level] < tr.minLevel {
+	if !tr.logs(ev) {
 		return
 	}
 
@@ -276,6 +673,19 @@ type Colors struct {
 	TIME_COLOR    string
 	CXT_KEY_COLOR string
 	STACK_COLOR   string //stacktrack
+
+	// optional colors of context values by type, values are not colored if empty
+	STRING_COLOR   string
+	NUMBER_COLOR   string
+	BOOL_COLOR     string
+	NULL_COLOR     string
+	DURATION_COLOR string // time.Duration and duration strings like "1.5s"
+
+	MESSAGE_COLORS map[Level]string // optional message style per level, set before use, see SetMessageStyle
+	LINE_COLORS    map[Level]string // optional style of the whole event text per level, set before use, see SetLineStyle
+
+	messageStyles atomic.Value // map[Level]string replacing MESSAGE_COLORS, see SetMessageStyle
+	lineStyles    atomic.Value // map[Level]string replacing LINE_COLORS, see SetLineStyle
 }
 
 type ioTransport struct {
@@ -287,138 +697,288 @@ type ioTransport struct {
 	ErrLog *log.Logger
 	FtlLog *log.Logger
 
-	Colors        *Colors
-	PrintRawEvent bool // Console only option, print sentry event as JSON instead of formated lines
+	Colors         *Colors
+	PrintRawEvent  bool // print the sentry event as one line of JSON instead of formated lines
+	PrettyRawEvent bool // indent the JSON of PrintRawEvent over several lines, for debugging
+	RawText        bool // keep control characters of event text, e.g. for trusted files
+	SingleLine     bool // write every event as exactly one line, see WithSingleLine
+	SyslogPriority bool // prefix every line with its <N> syslog priority, see WithSyslogPriority
+	MaxLineSize    int  // split longer lines, 0 doesn't split, see WithContainerMode
+
+	Format Format // TextFormat or a JSON format written one document per line
+
+	mu    sync.Mutex  // serializes document writes bypassing the log.Loggers
+	files []io.Closer // files opened by the transport, closed by Close
+
+	diskLow int32 // 1 drops writes while disk space is low, see WatchDiskSpace
 }
 
 // returns ioTransport with time only line prefix
-func NewIoTransport(stdout io.Writer, stderr io.Writer, minLogLevel int) *ioTransport {
+func NewIoTransport(stdout io.Writer, stderr io.Writer, minLogLevel Level) *ioTransport {
+	return NewTransport(stdout, WithErrWriter(stderr), WithMinLevel(minLogLevel))
+}
 
-	t := new(ioTransport)
+// returns ioTransport with time and date, or the error of opening the files
+func NewFileTransport(outFile string, errFile string, minLogLevel Level) (*ioTransport, error) {
 
-	t.minLevel = minLogLevel // minimum severity level for logging
-	t.PrintRawEvent = false  // console only option, print sentry event as JSON instead of formated lines
+	// If the file doesn't exist, create it, or append to the file
+	stdout, err := os.OpenFile(outFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	stderr := stdout
+	if outFile != errFile {
+		stderr, err = os.OpenFile(errFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			stdout.Close()
+			return nil, err
+		}
+	}
 
-	t.Colors = &Colors{ // default colors, could be changed after initialization
-		RESET_COLOR:   "\033[0m",
-		TIME_COLOR:    "\033[90m",
-		CXT_KEY_COLOR: "\033[36m",
-		STACK_COLOR:   "\033[31m",
+	t := NewTransport(stdout, WithErrWriter(stderr), WithMinLevel(minLogLevel),
+		WithColors(nil), WithTimeFormat(log.LstdFlags))
+
+	t.files = append(t.files, stdout)
+	if stderr != stdout {
+		t.files = append(t.files, stderr)
 	}
 
-	stdout.Write([]byte(t.Colors.TIME_COLOR)) // set time color start
+	return t, nil
+}
+func (t *ioTransport) Configure(options sentry.ClientOptions) {
+}
+
+func (t *ioTransport) SendEvent(ev *sentry.Event) {
 
-	t.DbgLog = log.New(stdout, "\033[95mDBG\033[37m ", //blue
-		log.Lmsgprefix|log.Ltime)
+	if !t.logs(ev) {
+		return
+	}
 
-	t.InfLog = log.New(stdout, "\033[92mINF\033[37m ", //green
-		log.Lmsgprefix|log.Ltime)
+	if t.PrintRawEvent {
+		t.writeRawEvent(ev)
+		return
+	}
 
-	t.WrnLog = log.New(stdout, "\033[93mWRN\033[37m ", //yellow
-		log.Lmsgprefix|log.Ltime)
+	if t.Format != TextFormat {
+		t.writeDocument(ev)
+		return
+	}
 
-	t.ErrLog = log.New(stderr, "\033[31mERR\033[37m ", //red
-		log.Lmsgprefix|log.Ltime)
+	l := t.logger(ev.Level)
+	if l == nil {
+		return
+	}
 
-	t.FtlLog = log.New(stderr, "\033[91mFTL\033[37m ", //red
-		log.Lmsgprefix|log.Ltime)
+	// every line starts and ends with complete color sequences, other output
+	// written to the same terminal keeps its colors
+	header := t.Colors.TIME_COLOR + timeHeader(ev.Timestamp, l.Flags()) + t.Colors.RESET_COLOR
+	if l.Flags()&log.Lmsgprefix != 0 {
+		header += l.Prefix()
+	} else {
+		header = l.Prefix() + header
+	}
 
-	return t
+	t.writeLine(ev, []byte(header+strings.TrimSuffix(formatEvent(ev, t.Colors, &out{raw: t.RawText, singleLine: t.SingleLine}), "\n")+t.Colors.RESET_COLOR))
 }
 
-// returns ioTransport with time and date
-func NewFileTransport(outFile string, errFile string, minLogLevel int) *ioTransport {
+// line time header of the log package flags, e.g. "2009/01/23 01:23:23 "
+func timeHeader(ts time.Time, flags int) string {
 
-	// If the file doesn't exist, create it, or append to the file
-	stdout, err := os.OpenFile(outFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		FTL(err)
+	if flags&log.LUTC != 0 {
+		ts = ts.UTC()
 	}
 
-	var stderr *os.File
-	if outFile == errFile {
-		stderr = stdout
-	} else {
-		stderr, err = os.OpenFile(errFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			FTL(err)
+	var b strings.Builder
+	if flags&log.Ldate != 0 {
+		b.WriteString(ts.Format("2006/01/02 "))
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		if flags&log.Lmicroseconds != 0 {
+			b.WriteString(ts.Format("15:04:05.000000 "))
+		} else {
+			b.WriteString(ts.Format("15:04:05 "))
 		}
 	}
+	return b.String()
+}
 
-	t := new(ioTransport)
+// FormatEvent returns the human readable text of the event as written by the
+// console and file transports: message, error, context fields and stacktrace,
+// without the level and time header. A nil style formats plain text. Control
+// characters and escape sequences of the event are escaped, see Sanitize.
+func FormatEvent(ev *sentry.Event, style *Colors) string {
+	return formatEvent(ev, style, new(out))
+}
 
-	t.minLevel = minLogLevel // Minimum severity level for logging
-	t.PrintRawEvent = false  // Console only option, print sentry event as JSON instead of formated lines
+// FormatEvent written to out, which holds the text options
+func formatEvent(ev *sentry.Event, style *Colors, out *out) string {
 
-	t.Colors = &Colors{} // empty colors strings
+	c := style
+	if c == nil {
+		c = &Colors{}
+	}
 
-	t.DbgLog = log.New(stdout, "DBG ",
-		log.Lmsgprefix|log.LstdFlags)
+	msgStyle, msgReset := c.messageStyle(senlogLevels[ev.Level]), ""
+	if msgStyle != "" {
+		msgReset = c.RESET_COLOR
+	}
 
-	t.InfLog = log.New(stdout, "INF ",
-		log.Lmsgprefix|log.LstdFlags)
+	if caller, ok := ev.Extra["caller"]; ok {
+		out.write(c.TIME_COLOR, out.text(fmt.Sprint(caller)), c.RESET_COLOR, " ")
+	}
+	if len(ev.Exception) > 0 {
+		out.write(msgStyle, out.text(ev.Message), " | ", out.text(ev.Exception[len(ev.Exception)-1].Value), msgReset) //last execption concates all error msgs
+		out.writeContexts(ev.Contexts, c)
+		if ev.Exception[0].Stacktrace != nil {
+			out.writeStacktrace(*ev.Exception[0].Stacktrace, c.STACK_COLOR, c.RESET_COLOR)
+		}
+	} else {
+		out.write(msgStyle, out.text(ev.Message), msgReset)
+		out.writeContexts(ev.Contexts, c)
+		if len(ev.Threads) > 0 && ev.Threads[0].Stacktrace != nil {
+			out.writeStacktrace(*ev.Threads[0].Stacktrace, c.STACK_COLOR, c.RESET_COLOR)
+		}
+	}
+
+	text := out.String()
+	if out.singleLine {
+		text = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\r", `\r`), "\n", `\n`)
+	}
 
-	t.WrnLog = log.New(stdout, "WRN ",
-		log.Lmsgprefix|log.LstdFlags)
+	if line := c.lineStyle(senlogLevels[ev.Level]); line != "" {
+		return highlight(text, line, c.RESET_COLOR)
+	}
+	return text
+}
 
-	t.ErrLog = log.New(stderr, "ERR ",
-		log.Lmsgprefix|log.LstdFlags)
+// text styled with line, restored after every reset and reset at every line end
+func highlight(text string, line string, reset string) string {
 
-	t.FtlLog = log.New(stderr, "FTL ",
-		log.Lmsgprefix|log.LstdFlags)
+	if reset == "" {
+		reset = "\033[0m"
+	} else {
+		text = strings.ReplaceAll(text, reset, reset+line)
+	}
 
-	return t
+	trimmed := strings.TrimSuffix(text, "\n")
+	end := reset + text[len(trimmed):]
+	return line + strings.ReplaceAll(trimmed, "\n", reset+"\n"+line) + end
 }
-func (t *ioTransport) Configure(options sentry.ClientOptions) {
+
+// write the sentry event JSON, the event carries its own level and timestamp
+func (t *ioTransport) writeRawEvent(ev *sentry.Event) {
+
+	var b []byte
+	var err error
+	if t.PrettyRawEvent {
+		b, err = json.MarshalIndent(ev, "", "\t")
+	} else {
+		b, err = json.Marshal(ev)
+	}
+	if err != nil {
+		diagnose(fmt.Errorf("could not encode event %s: %w", ev.EventID, err))
+		return
+	}
+
+	t.writeLine(ev, b)
 }
 
-func (t *ioTransport) SendEvent(ev *sentry.Event) {
+// write a JSON document line to the writer of the event level, without line prefix
+func (t *ioTransport) writeDocument(ev *sentry.Event) {
 
-	if senlogLevels[ev.Level] < t.minLevel {
+	b, err := formatDocument(t.Format, ev)
+	if err != nil {
+		diagnose(fmt.Errorf("could not encode event %s: %w", ev.EventID, err))
 		return
 	}
 
-	var log string
+	t.writeLine(ev, b)
+}
 
-	if t.PrintRawEvent {
-		//b, _ := json.Marshal(event)
-		b, _ := json.MarshalIndent(ev, "", "\t")
-		//fmt.Println("[SENTRY EVENT] " + string(b))
-		log = string(b)
-	} else {
+// write b as a line to the writer of the event level, without line prefix
+func (t *ioTransport) writeLine(ev *sentry.Event, b []byte) {
 
-		var out = new(out)
-		if len(ev.Exception) > 0 {
-			out.write(ev.Message, " | ", ev.Exception[len(ev.Exception)-1].Value) //last execption concates all error msgs
-			out.writeContexts(ev.Contexts, t.Colors.CXT_KEY_COLOR, t.Colors.RESET_COLOR)
-			out.writeStacktrace(*ev.Exception[0].Stacktrace, t.Colors.STACK_COLOR)
-		} else {
-			out.write(ev.Message)
-			out.writeContexts(ev.Contexts, t.Colors.CXT_KEY_COLOR, t.Colors.RESET_COLOR)
-		}
-		out.write(t.Colors.TIME_COLOR) // set color for the next line time header
+	l := t.logger(ev.Level)
+	if l == nil || atomic.LoadInt32(&t.diskLow) == 1 {
+		return
+	}
 
-		log = out.String()
+	prefix := ""
+	if t.SyslogPriority {
+		prefix = syslogPriorities[ev.Level]
 	}
 
-	switch ev.Level {
+	if t.MaxLineSize > 0 {
+		document := t.Format != TextFormat || t.PrintRawEvent && !t.PrettyRawEvent
+		b = splitLines(ev, b, t.MaxLineSize-len(prefix)-1, document) // -1 newline
+	}
+
+	if prefix != "" {
+		b = withPriority(b, prefix)
+	}
+
+	t.mu.Lock()
+	_, err := l.Writer().Write(append(b, '\n'))
+	t.mu.Unlock()
+	diagnose(err)
+}
+
+// SetLevelWriters writes lines of a level to all ws, e.g. DEBUG to a separate
+// file. Without writers the level is not written.
+func (t *ioTransport) SetLevelWriters(level Level, ws ...io.Writer) {
+
+	if level < DEBUG || level > FATAL {
+		return
+	}
+
+	var w io.Writer
+	switch len(ws) {
+	case 0:
+		w = io.Discard
+	case 1:
+		w = ws[0]
+	default:
+		w = io.MultiWriter(ws...)
+	}
+	t.logger(sentryLevels[level-1]).SetOutput(w)
+}
+
+// logger of the event level, its writer, prefix and flags are used for lines
+func (t *ioTransport) logger(level sentry.Level) *log.Logger {
+
+	switch level {
 	case sentry.LevelInfo:
-		t.InfLog.Output(2, log)
+		return t.InfLog
 	case sentry.LevelWarning:
-		t.WrnLog.Output(2, log)
+		return t.WrnLog
 	case sentry.LevelDebug:
-		t.DbgLog.Output(2, log)
+		return t.DbgLog
 	case sentry.LevelError:
-		t.ErrLog.Output(2, log)
+		return t.ErrLog
 	case sentry.LevelFatal:
-		t.FtlLog.Output(2, log)
+		return t.FtlLog
 	}
+	return nil
 }
 
 func (t *ioTransport) Flush(_ time.Duration) bool {
 	return true
 }
 
+// closes files opened by NewFileTransport, writers passed by the caller are left open
+func (t *ioTransport) Close() error {
+
+	var err error
+	for _, f := range t.files {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	t.files = nil
+	return err
+}
+
 func (t *ioTransport) SetColors(c *Colors) {
 
 	t.Colors = c
@@ -427,74 +987,201 @@ func (t *ioTransport) SetColors(c *Colors) {
 // output buffer
 type out struct {
 	bytes.Buffer
+	raw        bool // write event text unchanged, not sanitized
+	singleLine bool // one physical line, newlines written as \n
+}
+
+// event text as written, see Sanitize
+func (b *out) text(s string) string {
+	if b.raw {
+		return s
+	}
+	return Sanitize(s)
 }
 
 func (b *out) write(a ...any) {
 	fmt.Fprint(b, a...)
 }
 
+// ContextNaming selects how context names are written in text lines
+type ContextNaming int32
+
+const (
+	GroupedContexts  ContextNaming = iota // [http] method=GET path=/x
+	PrefixedContexts                      // http.method=GET http.path=/x
+)
+
+// SetContextNaming sets how named contexts are written by the console and file
+// transports. Fields of the default context are written without a name.
+func SetContextNaming(n ContextNaming) {
+	updateSettings(func(s *settings) { s.contextNaming = n })
+}
+
 // Print key value pairs of contexts
-func (b *out) writeContexts(ctxs map[string]interface{}, keyColor string, resetColor string) {
+func (b *out) writeContexts(ctxs map[string]interface{}, c *Colors) {
+
+	naming := loadSettings().contextNaming
 
-	for ctxKey, ctxValue := range ctxs {
-		switch ctxKey {
-		case "os", "device", "runtime":
+	// default context fields first, they would read as part of a preceding group
+	if fields, ok := ctxs["Default Context"].(map[string]interface{}); ok {
+		b.writeFields("", fields, c)
+	}
+
+	for _, ctxKey := range sortedKeys(ctxs) {
+		ctxValue := ctxs[ctxKey]
+		switch {
+		case ctxKey == "Default Context" || sentryContext(ctxKey):
 			// ignore
 		default:
-			//TODO: write context name (ctxKey)
-			for k, v := range ctxValue.(map[string]interface{}) {
-				bValue, _ := json.MarshalIndent(v, "", "\t")
-				fmt.Fprintf(b, " %s%s=%s%s", keyColor, k, resetColor, bValue)
+			prefix := ""
+			if naming == PrefixedContexts {
+				prefix = ctxKey + "."
+			} else {
+				fmt.Fprintf(b, " %s[%s]%s", c.CXT_KEY_COLOR, b.text(ctxKey), c.RESET_COLOR)
 			}
+			b.writeFields(prefix, ctxValue.(map[string]interface{}), c)
+		}
+	}
+}
+
+func (b *out) writeFields(prefix string, fields map[string]interface{}, c *Colors) {
+
+	for _, k := range sortedKeys(fields) {
+		v := fields[k]
+		var bValue []byte
+		var err error
+		if b.singleLine {
+			bValue, err = json.Marshal(v)
+		} else {
+			bValue, err = json.MarshalIndent(v, "", "\t")
+		}
+		if err != nil {
+			diagnose(fmt.Errorf("could not encode context field %s: %w", k, err))
+			bValue = []byte(fmt.Sprintf("%q", fmt.Sprint(v)))
+		}
+		fmt.Fprintf(b, " %s%s=%s", c.CXT_KEY_COLOR, b.text(prefix+k), c.RESET_COLOR)
+		if color := c.valueColor(v); color != "" {
+			fmt.Fprintf(b, "%s%s%s", color, bValue, c.RESET_COLOR)
+		} else {
+			b.Write(bValue)
 		}
 	}
 }
 
-func (b *out) writeStacktrace(st sentry.Stacktrace, stackColor string) {
+// color of a field value by its type, empty if not set in c
+func (c *Colors) valueColor(v interface{}) string {
 
-	fmt.Fprintf(b, "\n%s%s\n", stackColor, "Stacktrace:")
+	if p, ok := v.(piiValue); ok {
+		v = p.v
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return c.NULL_COLOR
+	case bool:
+		return c.BOOL_COLOR
+	case time.Duration:
+		return c.DURATION_COLOR
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return c.NUMBER_COLOR
+	case string:
+		if _, err := time.ParseDuration(v); err == nil && v != "0" { // e.g. "1.5s" of Duration.String()
+			return c.DURATION_COLOR
+		}
+		return c.STRING_COLOR
+	}
+	return ""
+}
+
+// keys in sorted order, for lines that are stable across events. JSON output
+// is sorted by encoding/json.
+func sortedKeys(m map[string]interface{}) []string {
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// every line is colored and reset on its own
+func (b *out) writeStacktrace(st sentry.Stacktrace, stackColor string, resetColor string) {
+
+	fmt.Fprintf(b, "%s\n%s%s%s\n", resetColor, stackColor, "Stacktrace:", resetColor)
 
 	for _, f := range st.Frames {
 
 		if f.ContextLine != "" {
-			fmt.Fprintf(b, "\t%s:%d >>  %s\n", f.AbsPath, f.Lineno, strings.TrimSpace(f.ContextLine))
+			fmt.Fprintf(b, "%s\t%s:%d >>  %s%s\n", stackColor, b.text(f.AbsPath), f.Lineno, b.text(strings.TrimSpace(f.ContextLine)), resetColor)
 
 		} else {
-			fmt.Fprintf(b, "\t%s:%d\n", f.AbsPath, f.Lineno)
+			fmt.Fprintf(b, "%s\t%s:%d%s\n", stackColor, b.text(f.AbsPath), f.Lineno, resetColor)
 		}
 	}
 }
 
 type SentryTransport struct {
-	httpSyncTransport *sentry.HTTPSyncTransport
+	httpTransport sentry.Transport // sync or async sentry http transport
+	rateLimited   uint64           // events dropped by Sentry's rate limits, see RateLimited
+	limitedUntil  int64            // unix nanoseconds until which Sentry's rate limit pauses sends
+	results       atomic.Value     // func(error) of the destination's circuit breaker, see reportResults
 	Logger
+
+	MaxEventSize    int // bytes of event JSON, larger events are trimmed, DefaultMaxEventSize if 0
+	MaxStringLength int // strings of trimmed events are cut to it, DefaultMaxStringLength if 0
+
+	// OutboxDir turns on guaranteed delivery: ERROR and FATAL events are
+	// durably written to the directory before they are sent and removed once
+	// Sentry acknowledged them, see ReplayOutbox. Empty disables the outbox.
+	OutboxDir string
+	dsnKey    string // names the outbox entries of the destination
 }
 
-func NewSentryTransport(minLogLevel int) *SentryTransport {
+// sends each event before returning
+func NewSentryTransport(minLogLevel Level) *SentryTransport {
 
 	tr := new(SentryTransport)
-	tr.httpSyncTransport = sentry.NewHTTPSyncTransport()
-	tr.minLevel = minLogLevel
+	tr.httpTransport = sentry.NewHTTPSyncTransport()
+	tr.SetLogLevel(minLogLevel)
+	return tr
+}
+
+// queues events and sends them in the background, call Flush before exiting
+func NewAsyncSentryTransport(minLogLevel Level) *SentryTransport {
+
+	tr := new(SentryTransport)
+	tr.httpTransport = sentry.NewHTTPTransport()
+	tr.SetLogLevel(minLogLevel)
 	return tr
 }
 
 func (tr *SentryTransport) Configure(options sentry.ClientOptions) {
 
 	//options.Transport = nil
-	tr.httpSyncTransport.Configure(options)
+	tr.httpTransport.Configure(tr.ackOutbox(options))
 }
 
 func (tr *SentryTransport) SendEvent(ev *sentry.Event) {
 
 	tr.Call(func(ev *sentry.Event) {
-		tr.httpSyncTransport.SendEvent(ev)
+		if tr.OutboxDir != "" && outboxed(ev) {
+			if err := tr.writeOutbox(ev); err != nil {
+				diagnose(fmt.Errorf("could not write outbox: %w", err))
+			}
+		}
+		if tr.limited() { // sentry-go would drop it too, without counting
+			return
+		}
+		ev, _ = fitEvent(ev, tr.MaxEventSize, tr.MaxStringLength)
+		tr.httpTransport.SendEvent(ev)
 	}, ev)
 
 }
 
 func (tr *SentryTransport) Flush(t time.Duration) bool {
 
-	return tr.httpSyncTransport.Flush(t)
+	return tr.httpTransport.Flush(t)
 }
 
 //
@@ -519,7 +1206,7 @@ type WebsocketTransport struct {
 	Logger
 }
 
-func NewWebsocketTransport(ws *websocket.Conn, minLogLevel int) *WebsocketTransport {
+func NewWebsocketTransport(ws *websocket.Conn, minLogLevel Level) *WebsocketTransport {
 
 	tr := new(WebsocketTransport)
 
@@ -550,7 +1237,7 @@ func (tr *WebsocketTransport) Flush(t time.Duration) bool {
 */
 /*
 //depricated
-func capture_(level int, e error, x *Context, msg string) {
+func capture_(level Level, e error, x *Context, msg string) {
 
 	if level < MinLogLevel {
 		return
@@ -572,28 +1259,4 @@ func capture_(level int, e error, x *Context, msg string) {
 		}
 	})
 }
-
-type MultiTransport struct {
-	transports []sentry.Transport
-}
-
-func NewMultiTransport(t ...sentry.Transport) *MultiTransport {
-
-	t := new(MultiTransport)
-	t.transports = t
-	return t
-}
-
-func (t *MultiTransport) Configure(options sentry.ClientOptions) {
-}
-
-func (t *MultiTransport) SendEvent(ev *sentry.Event) {
-	for _, transport := range t.transports {
-		transport.SendEvent(ev)
-	}
-}
-
-func (t *MultiTransport) Flush(_ time.Duration) bool {
-	return true
-}
 */
@@ -0,0 +1,118 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// MemoryLimits are the thresholds of WatchMemory, zero values are not checked
+type MemoryLimits struct {
+	MaxHeap         uint64  // bytes of allocated heap
+	MaxGoroutines   int     // number of goroutines
+	HeapGrowth      float64 // factor of heap growth, e.g. 2 warns each time the heap doubled
+	GoroutineGrowth float64 // factor of goroutine growth
+}
+
+// WatchMemory checks the heap and the goroutines every interval and logs a
+// WRN with the runtime stats when a limit is crossed, a basic leak detection.
+// A max limit warns again only after the value fell below it. Growth is
+// measured from the first check, after a warning from the value warned about.
+// The returned func stops the watchdog. An interval of 0 or less doesn't watch.
+//
+//	stop := senlog.WatchMemory(time.Minute, senlog.MemoryLimits{MaxHeap: 1 << 30, HeapGrowth: 2})
+//	defer stop()
+func WatchMemory(interval time.Duration, limits MemoryLimits) (stop func()) {
+
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		w := memoryWatch{limits: limits}
+		for {
+			w.check()
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }
+}
+
+type memoryWatch struct {
+	limits        MemoryLimits
+	heapBase      uint64 // heap growth is measured from
+	goroutineBase int
+	heapOver      bool // MaxHeap exceeded at the last check
+	goroutineOver bool
+}
+
+func (w *memoryWatch) check() {
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	goroutines := runtime.NumGoroutine()
+
+	if w.heapBase == 0 {
+		w.heapBase, w.goroutineBase = m.HeapAlloc, goroutines
+	}
+
+	l := w.limits
+	type crossing struct {
+		reason string
+		limit  interface{}
+	}
+	var crossed []crossing
+
+	if over := l.MaxHeap > 0 && m.HeapAlloc > l.MaxHeap; over != w.heapOver {
+		w.heapOver = over
+		if over {
+			crossed = append(crossed, crossing{"heap above limit", l.MaxHeap})
+		}
+	}
+	if over := l.MaxGoroutines > 0 && goroutines > l.MaxGoroutines; over != w.goroutineOver {
+		w.goroutineOver = over
+		if over {
+			crossed = append(crossed, crossing{"goroutines above limit", l.MaxGoroutines})
+		}
+	}
+	if l.HeapGrowth > 1 && float64(m.HeapAlloc) > float64(w.heapBase)*l.HeapGrowth {
+		crossed = append(crossed, crossing{"heap grew", l.HeapGrowth})
+		w.heapBase = m.HeapAlloc
+	}
+	if l.GoroutineGrowth > 1 && float64(goroutines) > float64(w.goroutineBase)*l.GoroutineGrowth {
+		crossed = append(crossed, crossing{"goroutines grew", l.GoroutineGrowth})
+		w.goroutineBase = goroutines
+	}
+
+	for _, c := range crossed {
+		Cxt("memory").
+			Set("heap_alloc", m.HeapAlloc).
+			Set("heap_sys", m.HeapSys).
+			Set("heap_objects", m.HeapObjects).
+			Set("num_gc", m.NumGC).
+			Set("goroutines", goroutines).
+			Set("limit", c.limit).
+			WRN("Memory watchdog: ", c.reason)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchMemoryWithoutInterval(t *testing.T) {
+
+	quiet(t)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		WatchMemory(interval, MemoryLimits{MaxHeap: 1})() // time.NewTicker panics for these
+	}
+	time.Sleep(10 * time.Millisecond) // a watchdog goroutine would have started
+}
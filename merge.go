@@ -0,0 +1,97 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "context"
+
+type carriedKey struct{}
+
+// WithContext returns a copy of ctx carrying the fields of x, merged over
+// fields ctx already carries. Events logged with the Ctx variants, e.g.
+// INFCtx(ctx, ...), get the carried fields merged under their own:
+//
+//	per-call fields > fields of the innermost WithContext > outer WithContext
+func WithContext(ctx context.Context, x *Context) context.Context {
+
+	if x == nil {
+		return ctx
+	}
+
+	carried, _ := ctx.Value(carriedKey{}).(*Context)
+	if carried == nil {
+		return context.WithValue(ctx, carriedKey{}, x)
+	}
+	return context.WithValue(ctx, carriedKey{}, carried.Merge(x))
+}
+
+// FromContext returns the fields carried by ctx, nil if there are none
+func FromContext(ctx context.Context) *Context {
+
+	carried, _ := ctx.Value(carriedKey{}).(*Context)
+	return carried
+}
+
+// fields carried by ctx merged under the fields of x
+func withCarried(ctx context.Context, x *Context) *Context {
+
+	if ctx == nil {
+		return x
+	}
+	carried, _ := ctx.Value(carriedKey{}).(*Context)
+	if carried == nil {
+		return x
+	}
+	return carried.Merge(x)
+}
+
+// Merge returns x with the contexts of other deep merged: groups of the same
+// name are merged field by field and nested maps recursively, values of other win.
+func (x *Context) Merge(other *Context) *Context {
+
+	if x == nil {
+		return nil
+	}
+	if other == nil {
+		return x
+	}
+
+	c := x.derive()
+	c.base = x.contexts()
+	for name, fields := range other.contexts() {
+		c.base[name] = mergeValue(c.base[name], fields)
+	}
+	c.fields, c.tail = nil, nil
+	if c.current == "" {
+		c.current = other.current
+	}
+	c.except = append(c.except, other.except...)
+
+	return c
+}
+
+// src merged over dst, maps are copied
+func mergeValue(dst, src interface{}) interface{} {
+
+	s, ok := src.(map[string]interface{})
+	if !ok {
+		return src
+	}
+
+	d, _ := dst.(map[string]interface{})
+	merged := make(map[string]interface{}, len(d)+len(s))
+	for k, v := range d {
+		merged[k] = v
+	}
+	for k, v := range s {
+		merged[k] = mergeValue(merged[k], v)
+	}
+	return merged
+}
@@ -0,0 +1,174 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// MultiTransport fans events of one destination out to several transports,
+// e.g. console and a JSON file under a single destination key
+type MultiTransport struct {
+	Logger // destination wide minimum level, DEBUG by default
+
+	mu       sync.Mutex   // serializes Add
+	children atomic.Value // []multiChild, replaced by Add
+}
+
+type multiChild struct {
+	transport sentry.Transport
+	minLevel  Level // used for children which are not a LeveledLogger
+}
+
+// NewMultiTransport returns a transport writing to all t. Children filter
+// events by their own level, children without a level receive all events.
+func NewMultiTransport(t ...sentry.Transport) *MultiTransport {
+
+	m := new(MultiTransport)
+	m.SetLogLevel(DEBUG)
+	children := make([]multiChild, 0, len(t))
+	for _, transport := range t {
+		children = append(children, multiChild{transport: transport, minLevel: DEBUG})
+	}
+	m.children.Store(children)
+	return m
+}
+
+// Add adds a child transport writing minLevel and up. The level of a
+// LeveledLogger child is set to minLevel. Children may be added while a
+// destination uses the transport, the level of the new child is then recorded
+// as a change, see Changes.
+func (m *MultiTransport) Add(transport sentry.Transport, minLevel Level) *MultiTransport {
+
+	from := ""
+	if l, ok := transport.(LeveledLogger); ok {
+		from = l.MinLogLevel().String()
+		l.SetLogLevel(minLevel)
+	}
+
+	m.mu.Lock()
+	current := m.list()
+	children := make([]multiChild, len(current), len(current)+1)
+	copy(children, current)
+	m.children.Store(append(children, multiChild{transport: transport, minLevel: minLevel}))
+	m.mu.Unlock()
+
+	if key, ok := destinationOf(m); ok {
+		if c, changed := recordChange(ChangedByAPI, "child_level", key, from, minLevel.String()); changed {
+			auditChanges(c)
+		}
+	}
+	return m
+}
+
+func (m *MultiTransport) list() []multiChild {
+	children, _ := m.children.Load().([]multiChild)
+	return children
+}
+
+// key of the destination using transport t
+func destinationOf(t sentry.Transport) (string, bool) {
+
+	for _, d := range destinations() {
+		if d.hub.Client().Transport == t {
+			return d.key, true
+		}
+	}
+	return "", false
+}
+
+// lowest level written by a child
+func (c multiChild) level() Level {
+	if l, ok := c.transport.(LeveledLogger); ok {
+		return l.MinLogLevel()
+	}
+	return c.minLevel
+}
+
+// MinLogLevel is the lowest level any child writes, but not below the
+// destination wide level
+func (m *MultiTransport) MinLogLevel() Level {
+
+	level := Level(FATAL + 1)
+	for _, c := range m.list() {
+		if l := c.level(); l < level {
+			level = l
+		}
+	}
+	if min := m.Logger.MinLogLevel(); level < min {
+		level = min
+	}
+	return level
+}
+
+func (m *MultiTransport) Configure(options sentry.ClientOptions) {
+	for _, c := range m.list() {
+		c.transport.Configure(options)
+	}
+}
+
+func (m *MultiTransport) SendEvent(ev *sentry.Event) {
+
+	if !m.logs(ev) {
+		return
+	}
+
+	for _, c := range m.list() {
+		if _, ok := c.transport.(LeveledLogger); !ok && senlogLevels[ev.Level] < c.minLevel && !levelExempt(ev) {
+			continue
+		}
+		c.transport.SendEvent(ev)
+	}
+}
+
+// Flush flushes all children in parallel, each within timeout. It returns
+// false if any child failed to flush.
+func (m *MultiTransport) Flush(timeout time.Duration) bool {
+
+	children := m.list()
+	var wg sync.WaitGroup
+	results := make([]bool, len(children))
+
+	for i, c := range children {
+		wg.Add(1)
+		go func(i int, transport sentry.Transport) {
+			defer wg.Done()
+			results[i] = transport.Flush(timeout)
+		}(i, c.transport)
+	}
+	wg.Wait()
+
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Close closes the children implementing io.Closer, returning the first error
+func (m *MultiTransport) Close() error {
+
+	var err error
+	for _, c := range m.list() {
+		if closer, ok := c.transport.(io.Closer); ok {
+			if e := closer.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
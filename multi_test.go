@@ -0,0 +1,137 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// transport without a level, keeping the levels of the events it receives
+type plainTransport struct {
+	mu     sync.Mutex
+	levels []sentry.Level
+}
+
+func (t *plainTransport) Configure(sentry.ClientOptions) {}
+func (t *plainTransport) Flush(time.Duration) bool       { return true }
+
+func (t *plainTransport) SendEvent(ev *sentry.Event) {
+	t.mu.Lock()
+	t.levels = append(t.levels, ev.Level)
+	t.mu.Unlock()
+}
+
+func (t *plainTransport) Levels() []sentry.Level {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]sentry.Level(nil), t.levels...)
+}
+
+var allLevels = []sentry.Level{sentry.LevelDebug, sentry.LevelInfo, sentry.LevelWarning, sentry.LevelError, sentry.LevelFatal}
+
+func TestMultiTransportFiltersByChildLevel(t *testing.T) {
+
+	all := new(plainTransport)
+	warnings := new(plainTransport)
+	severe := newRecordingTransport(DEBUG)
+
+	m := NewMultiTransport(all).Add(warnings, WARN).Add(severe, ERROR)
+	if l := severe.MinLogLevel(); l != ERROR {
+		t.Fatalf("Add set the leveled child to %v, want ERROR", l)
+	}
+	if l := m.MinLogLevel(); l != DEBUG {
+		t.Fatalf("MinLogLevel = %v, want DEBUG, the lowest child", l)
+	}
+
+	for _, level := range allLevels {
+		m.SendEvent(&sentry.Event{Level: level, Message: string(level)})
+	}
+
+	if got := all.Levels(); len(got) != 5 {
+		t.Errorf("child without a level got %v, want all levels", got)
+	}
+	if got := warnings.Levels(); len(got) != 3 || got[0] != sentry.LevelWarning {
+		t.Errorf("WARN child got %v, want warning and up", got)
+	}
+	if got := severe.Events(); len(got) != 2 || got[0].Level != sentry.LevelError {
+		t.Errorf("ERROR child got %d events, want error and fatal", len(got))
+	}
+}
+
+func TestMultiTransportDestinationLevelCapsChildren(t *testing.T) {
+
+	all := new(plainTransport)
+	m := NewMultiTransport(all)
+	m.SetLogLevel(ERROR)
+
+	if l := m.MinLogLevel(); l != ERROR {
+		t.Fatalf("MinLogLevel = %v, want the destination wide ERROR", l)
+	}
+	for _, level := range allLevels {
+		m.SendEvent(&sentry.Event{Level: level})
+	}
+	m.SendEvent(&sentry.Event{Level: sentry.LevelDebug, Logger: auditLoggerName})
+
+	if got := all.Levels(); len(got) != 3 || got[2] != sentry.LevelDebug {
+		t.Errorf("child got %v, want error, fatal and the audit event", got)
+	}
+}
+
+func TestMultiTransportFansOutToEveryChild(t *testing.T) {
+
+	quiet(t)
+	first := newRecordingTransport(DEBUG)
+	second := newRecordingTransport(DEBUG)
+	plain := new(plainTransport)
+	addTestDestination(t, "multi", sentry.ClientOptions{Transport: NewMultiTransport(first, second, plain)})
+
+	INF("fanned out")
+
+	for i, child := range []*recordingTransport{first, second} {
+		if msgs := child.Messages(); !contains(msgs, "fanned out") {
+			t.Errorf("child %d got %q, want the event", i, msgs)
+		}
+	}
+	if len(plain.Levels()) == 0 {
+		t.Error("child without a level got no event")
+	}
+}
+
+func TestMultiTransportAddRecordsLevelChange(t *testing.T) {
+
+	quiet(t)
+	m := NewMultiTransport(newRecordingTransport(DEBUG))
+	addTestDestination(t, "multi", sentry.ClientOptions{Transport: m})
+
+	done := make(chan struct{})
+	go func() { // logging while the child is added
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			INF("while adding")
+		}
+	}()
+	m.Add(newRecordingTransport(DEBUG), ERROR)
+	<-done
+
+	var recorded bool
+	for _, c := range CurrentConfig().Changes {
+		if c.Setting == "child_level" && c.Destination == "multi" && c.From == Level(DEBUG).String() && c.To == Level(ERROR).String() {
+			recorded = true
+		}
+	}
+	if !recorded {
+		t.Errorf("changes %+v, want the child level of multi", Changes())
+	}
+}
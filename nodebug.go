@@ -0,0 +1,19 @@
+//go:build senlog_nodebug
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+// The senlog_nodebug build tag compiles DEBUG logging out: DBG and
+// DBGCtx return right away and are inlined into nothing, Enabled(DEBUG) is
+// false and At(DEBUG) returns a nop context. Arguments of the calls are still
+// evaluated, guard expensive ones with Enabled(DEBUG). senlog has no TRACE level.
+const debugBuilt = false
@@ -0,0 +1,59 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// min time between two notices of the same format
+const noticeInterval = time.Second
+
+type noticeState struct {
+	last       time.Time
+	suppressed int
+}
+
+var (
+	noticesMu sync.Mutex
+	notices   = make(map[string]*noticeState) // by format
+)
+
+// reports a configuration notice of senlog, e.g. a missing destination, to
+// the diagnostics handler instead of the destinations. Notices of the same
+// format are throttled, so a misconfigured caller in a hot loop can't flood
+// the diagnostics; the next notice reports how many were suppressed.
+func notice(format string, args ...interface{}) {
+
+	noticesMu.Lock()
+	s, ok := notices[format]
+	if !ok {
+		s = new(noticeState)
+		notices[format] = s
+	}
+	t := time.Now()
+	if t.Sub(s.last) < noticeInterval {
+		s.suppressed++
+		noticesMu.Unlock()
+		return
+	}
+	suppressed := s.suppressed
+	s.last, s.suppressed = t, 0
+	noticesMu.Unlock()
+
+	err := fmt.Errorf(format, args...)
+	if suppressed > 0 {
+		err = fmt.Errorf("%w (%d similar notices suppressed)", err, suppressed)
+	}
+	diagnose(err)
+}
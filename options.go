@@ -0,0 +1,214 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"io"
+	"log"
+)
+
+// Option configures a transport created by NewTransport
+type Option func(*transportOptions)
+
+type transportOptions struct {
+	errWriter io.Writer // writer of ERR and FTL lines, defaults to the writer
+	minLevel  Level
+	colors    *Colors
+	timeFlags int // log package flags of the line time header
+	rawJSON   bool
+	prettyRaw bool
+	rawText   bool
+	oneLine   bool
+	priority  bool
+	container bool
+	format    Format
+
+	levelWriters map[Level][]io.Writer // writers of a level replacing the default one
+}
+
+// WithMinLevel sets the minimum severity level written, DEBUG by default
+func WithMinLevel(level Level) Option {
+	return func(o *transportOptions) {
+		o.minLevel = level
+	}
+}
+
+// WithColors sets the colors of the text output, nil writes plain text
+// without any escape sequences
+func WithColors(c *Colors) Option {
+	return func(o *transportOptions) {
+		o.colors = c
+	}
+}
+
+// WithTimeFormat sets the line time header as log package flags,
+// e.g. log.LstdFlags|log.Lmicroseconds. The default is log.Ltime.
+func WithTimeFormat(flags int) Option {
+	return func(o *transportOptions) {
+		o.timeFlags = flags
+	}
+}
+
+// WithRawJSON prints every event as one line of sentry event JSON instead of formated lines
+func WithRawJSON(on bool) Option {
+	return func(o *transportOptions) {
+		o.rawJSON = on
+	}
+}
+
+// WithPrettyRawJSON prints every event as indented sentry event JSON, for debugging
+func WithPrettyRawJSON(on bool) Option {
+	return func(o *transportOptions) {
+		o.rawJSON = on
+		o.prettyRaw = on
+	}
+}
+
+// WithRawText keeps control characters and escape sequences of messages and
+// fields in text lines, they are escaped by default, see Sanitize. JSON output
+// is always escaped by its encoding.
+func WithRawText(on bool) Option {
+	return func(o *transportOptions) {
+		o.rawText = on
+	}
+}
+
+// WithSingleLine writes every event as exactly one physical line, newlines of
+// messages and stacktraces are written as \n and fields as compact JSON, for
+// log collectors splitting records at line ends, e.g. docker logs or fluentd
+func WithSingleLine(on bool) Option {
+	return func(o *transportOptions) {
+		o.oneLine = on
+	}
+}
+
+// WithErrWriter writes ERR and FTL lines to w instead of the transport writer
+func WithErrWriter(w io.Writer) Option {
+	return func(o *transportOptions) {
+		o.errWriter = w
+	}
+}
+
+// WithLevelWriters writes lines of a level to all ws instead of the writer or
+// error writer, e.g. WARN to stdout and stderr or DEBUG to a separate file
+func WithLevelWriters(level Level, ws ...io.Writer) Option {
+	return func(o *transportOptions) {
+		if o.levelWriters == nil {
+			o.levelWriters = make(map[Level][]io.Writer)
+		}
+		o.levelWriters[level] = ws
+	}
+}
+
+// WithFormat sets the output format, TextFormat by default
+func WithFormat(f Format) Option {
+	return func(o *transportOptions) {
+		o.format = f
+	}
+}
+
+// WithSyslogPriority prefixes every line with the syslog priority of the event
+// level, like <3> for ERROR, so journald assigns it to lines of services run
+// by systemd. Lines of multi-line events get the prefix too. journald adds its
+// own timestamp, combine it with WithTimeFormat(0).
+func WithSyslogPriority(on bool) Option {
+	return func(o *transportOptions) {
+		o.priority = on
+	}
+}
+
+// WithContainerMode writes JSON lines which container runtimes don't split:
+// Docker splits lines longer than 16KB into fragments which log collectors
+// can't parse. Longer documents are written as several chunk lines instead,
+// see DockerLineSize. Text output is switched to JSONFormat, other formats kept.
+//
+//	senlog.NewTransport(os.Stderr, senlog.WithContainerMode(true), senlog.WithColors(nil))
+func WithContainerMode(on bool) Option {
+	return func(o *transportOptions) {
+		o.container = on
+	}
+}
+
+// line prefixes of DEBUG to FATAL
+var (
+	levelTags        = [...]string{"DBG ", "INF ", "WRN ", "ERR ", "FTL "}
+	coloredLevelTags = [...]string{
+		"\033[95mDBG\033[37m ", //blue
+		"\033[92mINF\033[37m ", //green
+		"\033[93mWRN\033[37m ", //yellow
+		"\033[31mERR\033[37m ", //red
+		"\033[91mFTL\033[37m ", //red
+	}
+)
+
+// default colors, could be changed after initialization
+func defaultColors() *Colors {
+	return &Colors{
+		RESET_COLOR:   "\033[0m",
+		TIME_COLOR:    "\033[90m",
+		CXT_KEY_COLOR: "\033[36m",
+		STACK_COLOR:   "\033[31m",
+	}
+}
+
+// NewTransport returns an ioTransport writing to w. Without options it
+// writes colored text lines with a time header, DEBUG and up.
+func NewTransport(w io.Writer, opts ...Option) *ioTransport {
+
+	o := transportOptions{
+		errWriter: w,
+		minLevel:  DEBUG,
+		colors:    defaultColors(),
+		timeFlags: log.Ltime,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.container && o.format == TextFormat && !o.rawJSON {
+		o.format = JSONFormat
+	}
+
+	t := new(ioTransport)
+
+	t.SetLogLevel(o.minLevel)
+	t.PrintRawEvent = o.rawJSON
+	t.PrettyRawEvent = o.prettyRaw
+	t.RawText = o.rawText
+	t.SingleLine = o.oneLine
+	t.SyslogPriority = o.priority
+	if o.container {
+		t.MaxLineSize = DockerLineSize
+	}
+	t.Format = o.format
+
+	// level tags are colored along with the other colors
+	tags := levelTags
+	if o.colors != nil {
+		t.Colors = o.colors
+		tags = coloredLevelTags
+	} else {
+		t.Colors = &Colors{} // empty colors strings
+	}
+
+	flags := log.Lmsgprefix | o.timeFlags
+
+	t.DbgLog = log.New(w, tags[0], flags)
+	t.InfLog = log.New(w, tags[1], flags)
+	t.WrnLog = log.New(w, tags[2], flags)
+	t.ErrLog = log.New(o.errWriter, tags[3], flags)
+	t.FtlLog = log.New(o.errWriter, tags[4], flags)
+
+	for level, ws := range o.levelWriters {
+		t.SetLevelWriters(level, ws...)
+	}
+
+	return t
+}
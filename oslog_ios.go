@@ -0,0 +1,86 @@
+//go:build ios && cgo
+
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+// os_log_with_type is a macro, it needs a constant format string
+static void senlog_os_log(os_log_t log, os_log_type_t type, const char *msg) {
+	os_log_with_type(log, type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// OSLogTransport writes events to the unified logging system of iOS, shown by
+// Xcode and Console.app, under a subsystem and category. Levels map to log
+// types: DEBUG to debug, INFO to info, WARN to default, ERROR to error and
+// FATAL to fault. It is the default console destination of iOS builds.
+type OSLogTransport struct {
+	Logger
+	log C.os_log_t
+}
+
+func NewOSLogTransport(subsystem string, category string, minLogLevel Level) *OSLogTransport {
+
+	s, c := C.CString(subsystem), C.CString(category)
+	defer C.free(unsafe.Pointer(s))
+	defer C.free(unsafe.Pointer(c))
+
+	t := new(OSLogTransport)
+	t.log = C.os_log_create(s, c)
+	t.SetLogLevel(minLogLevel)
+	return t
+}
+
+var osLogTypes = map[sentry.Level]C.os_log_type_t{
+	sentry.LevelDebug:   C.OS_LOG_TYPE_DEBUG,
+	sentry.LevelInfo:    C.OS_LOG_TYPE_INFO,
+	sentry.LevelWarning: C.OS_LOG_TYPE_DEFAULT,
+	sentry.LevelError:   C.OS_LOG_TYPE_ERROR,
+	sentry.LevelFatal:   C.OS_LOG_TYPE_FAULT,
+}
+
+func (t *OSLogTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *OSLogTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		logType, ok := osLogTypes[ev.Level]
+		if !ok {
+			logType = C.OS_LOG_TYPE_DEFAULT
+		}
+
+		msg := C.CString(strings.TrimSuffix(formatEvent(ev, nil, new(out)), "\n"))
+		C.senlog_os_log(t.log, logType, msg)
+		C.free(unsafe.Pointer(msg))
+	}, ev)
+}
+
+func (t *OSLogTransport) Flush(time.Duration) bool {
+	return true
+}
+
+func defaultConsoleTransport() sentry.Transport {
+	return NewOSLogTransport("com.github.ejazmughal.senlog", "default", DEBUG)
+}
@@ -0,0 +1,194 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+const outboxFilePrefix = "senlog-outbox-"
+
+// events of these levels are written to the outbox of a SentryTransport
+func outboxed(ev *sentry.Event) bool {
+	return ev.Type == "" && (ev.Level == sentry.LevelError || ev.Level == sentry.LevelFatal)
+}
+
+// outbox file of an event, named by the destination's DSN so transports can
+// share a directory
+func (tr *SentryTransport) outboxFile(id sentry.EventID) string {
+	return filepath.Join(tr.OutboxDir, outboxFilePrefix+tr.dsnKey+"-"+string(id)+".json")
+}
+
+// durably writes ev to the outbox before it is sent: written to a temporary
+// file, synced and renamed, so a crash never leaves a partial entry
+func (tr *SentryTransport) writeOutbox(ev *sentry.Event) error {
+
+	if err := os.MkdirAll(tr.OutboxDir, 0700); err != nil {
+		return err
+	}
+
+	ev = applyPIIPolicy(ev, PIIHash) // no raw personal data on disk
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(tr.OutboxDir, ".tmp-"+outboxFilePrefix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+	if e := tmp.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), tr.outboxFile(ev.EventID))
+}
+
+// ReplayOutbox re-sends the events left in the outbox of tr, e.g. by a previous
+// run which exited before Sentry acknowledged them. Events keep their IDs, so
+// Sentry drops those it already received. Acknowledged events leave the outbox.
+// ReportPreviousCrash replays the outboxes of all destinations.
+func (tr *SentryTransport) ReplayOutbox() (replayed int, err error) {
+
+	if tr.OutboxDir == "" {
+		return 0, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(tr.OutboxDir, outboxFilePrefix+tr.dsnKey+"-*.json"))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, file := range files {
+
+		b, e := os.ReadFile(file)
+		if e != nil {
+			err = e
+			continue
+		}
+
+		ev := new(sentry.Event)
+		if e := json.Unmarshal(b, ev); e != nil || ev.EventID == "" {
+			diagnose(fmt.Errorf("removing unreadable outbox entry %s", file))
+			os.Remove(file)
+			continue
+		}
+		if ev.Tags == nil {
+			ev.Tags = make(map[string]string)
+		}
+		ev.Tags["senlog.replay"] = "outbox"
+
+		if !replayable(ev) { // too old, see SetReplayMaxAge
+			os.Remove(file)
+			continue
+		}
+
+		ev, _ = fitEvent(ev, tr.MaxEventSize, tr.MaxStringLength)
+		tr.httpTransport.SendEvent(ev)
+		replayed++
+	}
+
+	return replayed, err
+}
+
+// acknowledges sends of outboxed events: the entry of an event is removed once
+// Sentry answered its request. Entries of events rejected for good, e.g. as
+// invalid, are removed too. Network errors, 429 and 5xx keep the entry. Rate
+// limits of the responses are noted, see RateLimited, and failed requests are
+// reported to the circuit breaker, see SetCircuitBreaker.
+type outboxAck struct {
+	tr   *SentryTransport
+	next http.RoundTripper
+}
+
+func (a *outboxAck) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	id := requestEventID(req)
+
+	resp, err := a.next.RoundTrip(req)
+	if err == nil {
+		a.tr.noteRateLimit(resp)
+	}
+	a.tr.report(resp, err)
+	if err != nil || id == "" || a.tr.OutboxDir == "" {
+		return resp, err
+	}
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusTooManyRequests || code >= 500:
+		return resp, err
+	case code >= 300:
+		diagnose(fmt.Errorf("sentry rejected event %s with status %d, removing it from the outbox", id, code))
+	}
+
+	if e := os.Remove(a.tr.outboxFile(id)); e != nil && !os.IsNotExist(e) {
+		diagnose(fmt.Errorf("could not prune outbox: %w", e))
+	}
+	return resp, err
+}
+
+// event ID of a store or envelope request, both start with a JSON object
+// holding it
+func requestEventID(req *http.Request) sentry.EventID {
+
+	if req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	var head struct {
+		EventID sentry.EventID `json:"event_id"`
+	}
+	if err := json.NewDecoder(io.LimitReader(body, 1<<20)).Decode(&head); err != nil {
+		return ""
+	}
+	return head.EventID
+}
+
+// wraps the HTTP transport of options to acknowledge outbox entries and note
+// rate limits. A custom HTTPClient can't be wrapped, entries are then only
+// removed by replays.
+func (tr *SentryTransport) ackOutbox(options sentry.ClientOptions) sentry.ClientOptions {
+
+	h := sha256.Sum256([]byte(strings.TrimSpace(options.Dsn)))
+	tr.dsnKey = hex.EncodeToString(h[:4])
+
+	if options.HTTPClient != nil {
+		return options
+	}
+	next := options.HTTPTransport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	options.HTTPTransport = &outboxAck{tr: tr, next: next}
+	return options
+}
@@ -0,0 +1,206 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// PIIPolicy defines what a destination receives for fields set with SetPII
+type PIIPolicy int32
+
+const (
+	PIIKeep     PIIPolicy = iota // raw value, the default
+	PIIHash                      // Pseudonym of the value with the salt of SetPIISalt
+	PIITokenize                  // token stable within the process run only
+	PIIDrop                      // field removed
+)
+
+// personal data field value, replaced per destination before sending
+type piiValue struct {
+	v interface{}
+}
+
+// never leak the marker, e.g. when an event is serialized before the policy is applied
+func (p piiValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.v)
+}
+
+// key of PIITokenize, random per process
+var tokenKey = func() []byte {
+	k := make([]byte, 32)
+	rand.Read(k)
+	return k
+}()
+
+var (
+	piiSaltValue atomic.Value // string
+	randomSalt   string       // used until SetPIISalt, see piiSalt
+	randomOnce   sync.Once
+)
+
+// SetPIISalt sets the secret salt used by the PIIHash policy. Until it is set
+// a random salt of the process is used, pseudonyms then differ between runs.
+func SetPIISalt(salt string) {
+	piiSaltValue.Store(salt)
+}
+
+// salt of PIIHash, never empty: an HMAC with an empty key is a plain hash,
+// reversible by hashing guessed values
+func piiSalt() string {
+
+	if salt, _ := piiSaltValue.Load().(string); salt != "" {
+		return salt
+	}
+
+	randomOnce.Do(func() {
+		k := make([]byte, 32)
+		rand.Read(k)
+		randomSalt = hex.EncodeToString(k)
+		diagnose(errors.New("PII hashed without SetPIISalt, using a random salt: pseudonyms differ between process runs"))
+	})
+	return randomSalt
+}
+
+// Pseudonym returns a stable HMAC-SHA256 pseudonym of v keyed with salt. The
+// same value and salt give the same pseudonym, so events stay correlatable
+// without exposing the raw identifier. Keep salt secret, a known salt allows
+// guessing low-entropy values like emails.
+func Pseudonym(v interface{}, salt string) string {
+
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(fmt.Sprint(v)))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// HashField sets field k to the Pseudonym of v
+func HashField(k string, v interface{}, salt string) *Context {
+	return Set(k, Pseudonym(v, salt))
+}
+
+// HashField sets field k to the Pseudonym of v
+func (x *Context) HashField(k string, v interface{}, salt string) *Context {
+	return x.Set(k, Pseudonym(v, salt))
+}
+
+// SetPII sets a field holding personal data, see SetPIIPolicy
+func SetPII(k string, v interface{}) *Context {
+	return Cxt("Default Context").SetPII(k, v)
+}
+
+// SetPII sets a field holding personal data, see SetPIIPolicy
+func (x *Context) SetPII(k string, v interface{}) *Context {
+
+	if x == nil {
+		return nil
+	}
+	return x.Set(k, piiValue{v})
+}
+
+// SetPIIPolicy sets how a destination receives fields set with SetPII, e.g.
+// raw values to a secured audit file but hashes to Sentry
+func SetPIIPolicy(destinationKey string, policy PIIPolicy) {
+
+	d, exists := lookup(destinationKey)
+	if !exists { // destination doesn't exist
+		notice("cannot set PII policy, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	atomic.StoreInt32(&d.pii, int32(policy))
+}
+
+func (d *destination) piiPolicy() PIIPolicy {
+	return PIIPolicy(atomic.LoadInt32(&d.pii))
+}
+
+// copy of ev with PII fields replaced according to policy
+func applyPIIPolicy(ev *sentry.Event, policy PIIPolicy) *sentry.Event {
+
+	c := *ev
+	c.Contexts = make(map[string]interface{}, len(ev.Contexts))
+
+	for name, fields := range ev.Contexts {
+
+		m, ok := fields.(map[string]interface{})
+		if !ok {
+			c.Contexts[name] = fields
+			continue
+		}
+
+		r := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			p, ok := v.(piiValue)
+			if !ok {
+				r[k] = v
+				continue
+			}
+			if v, keep := piiReplacement(p, policy); keep {
+				r[k] = v
+			}
+		}
+		c.Contexts[name] = r
+	}
+
+	return &c
+}
+
+// replaces the PII fields of r, which the caller owns, e.g. a cloneRecord,
+// according to policy
+func resolvePII(r *Record, policy PIIPolicy) {
+
+	resolvePIIFields(r.Fields, policy)
+	for _, fields := range r.Contexts {
+		resolvePIIFields(fields, policy)
+	}
+}
+
+// replaces the PII fields of a context, see resolvePII
+func resolvePIIFields(fields map[string]interface{}, policy PIIPolicy) {
+
+	for k, v := range fields {
+		p, ok := v.(piiValue)
+		if !ok {
+			continue
+		}
+		if v, keep := piiReplacement(p, policy); keep {
+			fields[k] = v
+		} else {
+			delete(fields, k)
+		}
+	}
+}
+
+// value of a PII field under policy, false if the field is dropped
+func piiReplacement(p piiValue, policy PIIPolicy) (interface{}, bool) {
+
+	switch policy {
+	case PIIKeep:
+		return p.v, true
+	case PIIHash:
+		return Pseudonym(p.v, piiSalt()), true
+	case PIITokenize:
+		mac := hmac.New(sha256.New, tokenKey)
+		mac.Write([]byte(fmt.Sprint(p.v)))
+		return "tok:" + hex.EncodeToString(mac.Sum(nil)[:12]), true
+	}
+	return nil, false
+}
@@ -0,0 +1,90 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// value of field k of the events' named contexts, and whether it is set
+func field(ev *sentry.Event, name string, k string) (interface{}, bool) {
+	m, _ := ev.Contexts[name].(map[string]interface{})
+	v, ok := m[k]
+	return v, ok
+}
+
+func TestPIIPolicyOnEveryPath(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	SetPIIPolicy("rec", PIIDrop)
+	SetAuditSink("rec", true)
+
+	paths := map[string]func(){
+		"log call": func() { SetPII("email", "a@example.com").INF("log call") },
+		"carried": func() {
+			ctx := WithContext(context.Background(), SetPII("email", "a@example.com"))
+			INFCtx(ctx, "carried")
+		},
+		"forwarded": func() {
+			CaptureSentryEvent(&sentry.Event{
+				Message:  "forwarded",
+				Level:    sentry.LevelInfo,
+				Contexts: map[string]interface{}{"Default Context": map[string]interface{}{"email": piiValue{"a@example.com"}}},
+			})
+		},
+		"audit": func() {
+			if err := Audit("audit", "actor", "target", map[string]interface{}{"email": piiValue{"a@example.com"}}); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+
+	for name, log := range paths {
+		log()
+		events := rec.Events()
+		ev := events[len(events)-1]
+		for ctx := range ev.Contexts {
+			if v, ok := field(ev, ctx, "email"); ok {
+				t.Errorf("%s: email %v sent to a PIIDrop destination", name, v)
+			}
+		}
+	}
+}
+
+func TestPIIHashNeverUsesAnEmptySalt(t *testing.T) {
+
+	quiet(t)
+	SetPIISalt("")
+
+	salt := piiSalt()
+	if salt == "" {
+		t.Fatal("PIIHash salt is empty")
+	}
+	if piiSalt() != salt {
+		t.Error("random salt changed within the process")
+	}
+
+	got, _ := piiReplacement(piiValue{"a@example.com"}, PIIHash)
+	if got == Pseudonym("a@example.com", "") {
+		t.Error("PIIHash is a hash without key")
+	}
+
+	SetPIISalt("secret")
+	defer SetPIISalt("")
+	if got, _ := piiReplacement(piiValue{"a@example.com"}, PIIHash); got != Pseudonym("a@example.com", "secret") {
+		t.Errorf("PIIHash ignores the salt set: %v", got)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// TransportFactory creates a sink from its configuration, e.g. a section of a
+// config file, see RegisterTransportFactory
+type TransportFactory func(config map[string]interface{}) (Sink, error)
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[string]TransportFactory{
+		"console": consoleSink,
+		"file":    fileSink,
+	}
+)
+
+// RegisterTransportFactory makes a transport available by name to
+// AddConfiguredDestination, typically in the init func of the package
+// implementing it. It panics if name is registered twice or factory is nil.
+// "console" and "file" are built in.
+func RegisterTransportFactory(name string, factory func(config map[string]interface{}) (Sink, error)) {
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("senlog: RegisterTransportFactory factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("senlog: RegisterTransportFactory called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// TransportFactories returns the names of the registered transports, sorted
+func TransportFactories() []string {
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewConfiguredSink creates a sink with the factory registered as name
+func NewConfiguredSink(name string, config map[string]interface{}) (Sink, error) {
+
+	factoriesMu.Lock()
+	factory, ok := factories[name]
+	factoriesMu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("senlog: no transport registered as %q", name)
+	}
+	return factory(config)
+}
+
+// AddConfiguredDestination adds a destination sending events of minLogLevel
+// and up to a sink created by the transport registered as name:
+//
+//	senlog.AddConfiguredDestination("audit", "file", senlog.INFO, map[string]interface{}{"path": "audit.log"})
+func AddConfiguredDestination(key string, name string, minLogLevel Level, config map[string]interface{}) error {
+
+	s, err := NewConfiguredSink(name, config)
+	if err != nil {
+		return err
+	}
+
+	if err := AddSink(key, s, minLogLevel); err != nil {
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+// formats by config name
+var formatNames = map[string]Format{
+	"text":          TextFormat,
+	"ecs":           ECSFormat,
+	"otel":          OTelFormat,
+	"json":          JSONFormat,
+	"cloud-logging": CloudLoggingFormat,
+	"lambda":        LambdaFormat,
+}
+
+// option "format" of config, text if not set
+func configFormat(config map[string]interface{}) (Format, error) {
+
+	v, ok := config["format"]
+	if !ok {
+		return TextFormat, nil
+	}
+	name, _ := v.(string)
+	f, ok := formatNames[name]
+	if !ok {
+		return 0, fmt.Errorf("senlog: unknown format %v", v)
+	}
+	return f, nil
+}
+
+// console: stdout and stderr, option "format"
+func consoleSink(config map[string]interface{}) (Sink, error) {
+
+	f, err := configFormat(config)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{WithErrWriter(os.Stderr), WithFormat(f)}
+	if f != TextFormat {
+		opts = append(opts, WithColors(nil))
+	}
+	return TransportSink(NewTransport(os.Stdout, opts...)), nil
+}
+
+// file: options "path", "errors" for a separate file of ERROR and FATAL, and "format"
+func fileSink(config map[string]interface{}) (Sink, error) {
+
+	path, _ := config["path"].(string)
+	if path == "" {
+		return nil, errors.New("senlog: file transport requires a path")
+	}
+	errPath, _ := config["errors"].(string)
+	if errPath == "" {
+		errPath = path
+	}
+
+	f, err := configFormat(config)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := NewFileTransport(path, errPath, DEBUG)
+	if err != nil {
+		return nil, err
+	}
+	t.Format = f
+	return TransportSink(t), nil
+}
@@ -0,0 +1,124 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// registers a factory for the test
+func registerTestFactory(t *testing.T, name string, factory TransportFactory) {
+
+	t.Helper()
+	RegisterTransportFactory(name, factory)
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		delete(factories, name)
+		factoriesMu.Unlock()
+	})
+}
+
+func TestConfiguredDestinationOfRegisteredFactory(t *testing.T) {
+
+	quiet(t)
+	sink := new(recordingSink)
+	var got map[string]interface{}
+	registerTestFactory(t, "recording", func(config map[string]interface{}) (Sink, error) {
+		got = config
+		return sink, nil
+	})
+
+	if err := AddConfiguredDestination("plugin", "recording", WARN, map[string]interface{}{"topic": "logs"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RemoveDestination("plugin") })
+
+	INF("below")
+	WRN("sent")
+
+	if got["topic"] != "logs" {
+		t.Errorf("factory got config %v", got)
+	}
+	var msgs []string
+	for _, r := range sink.Records() {
+		if r.Msg == "below" || r.Msg == "sent" { // not a setup notice
+			msgs = append(msgs, r.Msg)
+		}
+	}
+	if len(msgs) != 1 || msgs[0] != "sent" {
+		t.Errorf("sink got %q, want WARN and up", msgs)
+	}
+	if names := strings.Join(TransportFactories(), " "); names != "console file recording" {
+		t.Errorf("factories %s", names)
+	}
+}
+
+func TestRegisterTransportFactoryPanics(t *testing.T) {
+
+	factory := func(map[string]interface{}) (Sink, error) { return nil, errors.New("unused") }
+	registerTestFactory(t, "twice", factory)
+
+	for name, fn := range map[string]func(){
+		"twice": func() { RegisterTransportFactory("twice", factory) },
+		"nil":   func() { RegisterTransportFactory("nil", nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: registered, want a panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestConfiguredDestinationErrors(t *testing.T) {
+
+	registerTestFactory(t, "failing", func(map[string]interface{}) (Sink, error) {
+		return nil, errors.New("no broker")
+	})
+
+	for _, name := range []string{"failing", "unknown"} {
+		if err := AddConfiguredDestination("plugin", name, INFO, nil); err == nil {
+			t.Errorf("%s: added, want an error", name)
+		}
+	}
+	if err := AddConfiguredDestination("plugin", "file", INFO, nil); err == nil {
+		t.Error("file without path added, want an error")
+	}
+	if err := AddConfiguredDestination("plugin", "console", INFO, map[string]interface{}{"format": "xml"}); err == nil {
+		t.Error("console with unknown format added, want an error")
+	}
+}
+
+func TestFileFactoryWritesJSON(t *testing.T) {
+
+	quiet(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := AddConfiguredDestination("file", "file", INFO, map[string]interface{}{"path": path, "format": "json"}); err != nil {
+		t.Fatal(err)
+	}
+	INF("to the file")
+	RemoveDestination("file")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"msg":"to the file"`) {
+		t.Errorf("file has\n%s", b)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SetReportCaller adds the file:line of the log call to every event, as extra
+// data "caller". Text output shows it before the message.
+func SetReportCaller(on bool) {
+	updateSettings(func(s *settings) { s.reportCaller = on })
+}
+
+// DevMode reconfigures the "console" destination for development: colored
+// text with source lines, DEBUG and up
+func DevMode() error {
+
+	SetReportCaller(true)
+	return setConsoleTransport(NewIoTransport(os.Stdout, os.Stderr, DEBUG))
+}
+
+// ProdMode reconfigures the "console" destination for production: one JSON
+// document per line without colors, INFO and up
+func ProdMode() error {
+
+	SetReportCaller(false)
+
+	return setConsoleTransport(NewTransport(os.Stdout, WithErrWriter(os.Stderr),
+		WithMinLevel(INFO), WithColors(nil), WithFormat(JSONFormat)))
+}
+
+// use transport for the console destination, keeping its other settings
+func setConsoleTransport(transport sentry.Transport) error {
+	return setTransport("console", transport)
+}
+
+// bind a new client with transport to an existing destination, or add it
+func setTransport(key string, transport sentry.Transport) error {
+
+	d, exists := lookup(key)
+	if !exists {
+		return AddDestination(key, sentry.ClientOptions{Transport: transport})
+	}
+
+	options := d.hub.Client().Options()
+	options.Transport = transport
+	return d.rebind(options)
+}
+
+// file:line of the first caller outside senlog
+func caller() string {
+
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		f, more := frames.Next()
+		if !internalFunction(f.Function) {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// Init adds the "sentry" destination for dsn with an async transport logging
+// INFO and up, all events sampled, tagged with env and release. The console
+// destination is kept. The returned func waits at most FlushTimeout for
+// background sends and flushes all destinations, defer it in main:
+//
+//	flush, err := senlog.Init(os.Getenv("SENTRY_DSN"), "production", version)
+//	if err != nil {
+//		senlog.FTL(err, "Could not initialize logging")
+//	}
+//	defer flush()
+func Init(dsn string, env string, release string) (func(), error) {
+
+	err := AddDestination("sentry", sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: env,
+		Release:     release,
+		SampleRate:  1.0,
+		Transport:   NewAsyncSentryTransport(INFO),
+	})
+	if err != nil {
+		return func() {}, err
+	}
+
+	return func() {
+		waitPending(FlushTimeout)
+		flush(FlushTimeout)
+	}, nil
+}
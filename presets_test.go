@@ -0,0 +1,38 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestWaitPendingIsBounded(t *testing.T) {
+
+	quiet(t)
+
+	hung := newHungTransport(t)
+	addTestDestination(t, "hung", sentry.ClientOptions{Transport: hung})
+	SetSendTimeout("hung", 10*time.Millisecond)
+	hung.hang()
+
+	INF("to the hung backend")
+
+	start := time.Now()
+	if waitPending(50 * time.Millisecond) {
+		t.Fatal("waitPending reported a hung send as done")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("waitPending took %s", d)
+	}
+}
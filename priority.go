@@ -0,0 +1,47 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+)
+
+// SetPriorityLanes sets whether ERROR and FATAL events have priority, on by
+// default: they are never sampled out, and under parallel dispatch they are
+// never dropped by the destination's backpressure, see SetBackpressure, and
+// sent before queued events of lower levels. With SetOrdering they keep their
+// place in their sequence instead, after the events logged before them.
+func SetPriorityLanes(on bool) {
+	updateSettings(func(s *settings) { s.priorityLanes = on })
+}
+
+// whether r takes the priority lane
+func priority(r *Record) bool {
+	return loadSettings().priorityLanes && r.Level >= ERROR
+}
+
+// sets the share of events the destination sends, its client doesn't sample
+func (d *destination) setSampleRate(rate float64) {
+
+	if rate <= 0 || rate > 1 { // sentry-go's default for 0
+		rate = 1
+	}
+	atomic.StoreUint64(&d.sampleRate, math.Float64bits(rate))
+}
+
+// whether the destination samples r out, priority events are always sent
+func (d *destination) sampledOut(r *Record) bool {
+
+	rate := math.Float64frombits(atomic.LoadUint64(&d.sampleRate))
+	return rate < 1 && !priority(r) && rand.Float64() >= rate
+}
@@ -0,0 +1,79 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// SetCrashProfiles makes FTL write pprof profiles beside the crash marker, see
+// SetCrashDir: the heap and goroutine profiles, and a CPU profile of length cpu
+// if cpu > 0, which delays the exit by cpu. The file names are listed in the
+// crash report and sent with the replayed event, as extra data "profiles".
+// ReportPreviousCrash keeps the profile files for inspection with go tool pprof.
+func SetCrashProfiles(on bool, cpu time.Duration) {
+	updateSettings(func(s *settings) { s.crashProfiles, s.crashCPUProfile = on, cpu })
+}
+
+// writes the profiles of a crash to dir, returns the files written
+func writeCrashProfiles(dir string, ts time.Time) []string {
+
+	var files []string
+	base := filepath.Join(dir, fmt.Sprintf("%s%d", crashFilePrefix, ts.UnixNano()))
+
+	for _, name := range []string{"heap", "goroutine"} {
+		file := base + "." + name + ".pprof"
+		if err := writeProfile(file, func(f *os.File) error {
+			return pprof.Lookup(name).WriteTo(f, 0)
+		}); err != nil {
+			diagnose(fmt.Errorf("could not write %s profile: %w", name, err))
+			continue
+		}
+		files = append(files, file)
+	}
+
+	if cpu := loadSettings().crashCPUProfile; cpu > 0 {
+		file := base + ".cpu.pprof"
+		err := writeProfile(file, func(f *os.File) error {
+			if err := pprof.StartCPUProfile(f); err != nil {
+				return err // e.g. already profiling
+			}
+			time.Sleep(cpu)
+			pprof.StopCPUProfile()
+			return nil
+		})
+		if err != nil {
+			diagnose(fmt.Errorf("could not write cpu profile: %w", err))
+		} else {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+func writeProfile(file string, write func(f *os.File) error) error {
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,126 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// routing profiles, a profile activates the destinations of its groups
+var (
+	profilesMu    sync.Mutex
+	profiles      = make(map[string][]string) // groups by profile name
+	activeProfile string                      // empty: all destinations are active
+)
+
+// SetDestinationGroups puts a destination into groups, e.g. "observability",
+// "audit" or "debug". While a profile is active, grouped destinations receive
+// events only if one of their groups belongs to it. Destinations without
+// groups always receive events.
+func SetDestinationGroups(destinationKey string, groups ...string) {
+
+	d, exists := lookup(destinationKey)
+	if !exists {
+		notice("cannot set groups, log destination %q doesn't exist", destinationKey)
+		return
+	}
+
+	d.mu.Lock()
+	from := groupsString(d.groups)
+	d.groups = append([]string(nil), groups...)
+	d.mu.Unlock()
+
+	profilesMu.Lock()
+	d.activate(activeProfile, profiles[activeProfile])
+	profilesMu.Unlock()
+
+	if c, changed := recordChange(ChangedByAPI, "groups", destinationKey, from, groupsString(groups)); changed {
+		auditChanges(c)
+	}
+}
+
+// DefineProfile defines or redefines a routing profile as the destination
+// groups it activates, e.g.
+//
+//	senlog.DefineProfile("normal", "observability", "audit")
+//	senlog.DefineProfile("incident", "observability", "audit", "debug")
+//	senlog.ActivateProfile("normal")
+func DefineProfile(name string, groups ...string) {
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+
+	profiles[name] = append([]string(nil), groups...)
+	if name == activeProfile {
+		activateLocked(name)
+	}
+}
+
+// ActivateProfile switches routing to a defined profile, an empty name
+// activates all destinations again
+func ActivateProfile(name string) error {
+
+	profilesMu.Lock()
+
+	if _, ok := profiles[name]; !ok && name != "" {
+		profilesMu.Unlock()
+		return errors.New("Routing profile doesn't exist: " + name)
+	}
+	from := activeProfile
+	activateLocked(name)
+	profilesMu.Unlock()
+
+	notice("activated routing profile %q", name)
+	if c, changed := recordChange(ChangedByAPI, "profile", "", from, name); changed {
+		auditChanges(c)
+	}
+	return nil
+}
+
+// ActiveProfile returns the name of the active profile, empty if none is
+func ActiveProfile() string {
+
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	return activeProfile
+}
+
+func activateLocked(name string) {
+
+	activeProfile = name
+	for _, d := range destinations() {
+		d.activate(name, profiles[name])
+	}
+}
+
+// sets whether d receives events under the profile of groups
+func (d *destination) activate(profile string, groups []string) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	active := profile == "" || len(d.groups) == 0
+	for _, g := range groups {
+		for _, dg := range d.groups {
+			if g == dg {
+				active = true
+			}
+		}
+	}
+
+	if active {
+		atomic.StoreInt32(&d.inactive, 0)
+	} else {
+		atomic.StoreInt32(&d.inactive, 1)
+	}
+}
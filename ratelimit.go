@@ -0,0 +1,85 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// pause of sends after a 429 response without Retry-After, as in sentry-go
+const defaultRetryAfter = time.Minute
+
+// transports counting the events dropped by rate limits, see Stats
+type rateLimitCounter interface {
+	RateLimited() uint64
+	resetRateLimited()
+}
+
+// RateLimited returns the number of events dropped by Sentry's rate limits:
+// answered with 429, or not sent while the limit was in force. Responses are
+// only seen without a custom HTTPClient in the client options.
+func (tr *SentryTransport) RateLimited() uint64 {
+	return atomic.LoadUint64(&tr.rateLimited)
+}
+
+func (tr *SentryTransport) resetRateLimited() {
+	atomic.StoreUint64(&tr.rateLimited, 0)
+}
+
+// reports the outcome of each request to fn, see SetCircuitBreaker
+func (tr *SentryTransport) reportResults(fn func(error)) {
+	tr.results.Store(fn)
+}
+
+// reports the outcome of a request: an error, an error response or nil
+func (tr *SentryTransport) report(resp *http.Response, err error) {
+
+	fn, ok := tr.results.Load().(func(error))
+	switch {
+	case !ok:
+	case err != nil:
+		fn(err)
+	case resp.StatusCode == http.StatusTooManyRequests: // not a failure, see noteRateLimit
+	case resp.StatusCode >= 300:
+		fn(fmt.Errorf("sentry answered %s", resp.Status))
+	default:
+		fn(nil)
+	}
+}
+
+// notes the rate limit of a response from Sentry
+func (tr *SentryTransport) noteRateLimit(resp *http.Response) {
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	atomic.AddUint64(&tr.rateLimited, 1)
+
+	retryAfter := defaultRetryAfter
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+		retryAfter = time.Duration(s) * time.Second
+	}
+	atomic.StoreInt64(&tr.limitedUntil, time.Now().Add(retryAfter).UnixNano())
+}
+
+// whether sends are paused by a rate limit, ev is counted as dropped then
+func (tr *SentryTransport) limited() bool {
+
+	if time.Now().UnixNano() >= atomic.LoadInt64(&tr.limitedUntil) {
+		return false
+	}
+	atomic.AddUint64(&tr.rateLimited, 1)
+	return true
+}
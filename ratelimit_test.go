@@ -0,0 +1,56 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestRateLimitedEventsAreCounted(t *testing.T) {
+
+	quiet(t)
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://key@", 1) + "/1"
+	addTestDestination(t, "limited", sentry.ClientOptions{Dsn: dsn, Transport: NewSentryTransport(DEBUG)})
+	ResetStats()
+
+	Except("console").INF("answered 429")
+	Except("console").INF("not sent")
+
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("%d requests, want 1 while the rate limit is in force", n)
+	}
+	stats := Stats()["limited"]
+	if stats.RateLimited != 2 || stats.Dropped != 2 {
+		t.Errorf("stats = %+v, want 2 rate limited and dropped", stats)
+	}
+	if n := Dropped("limited"); n != 2 {
+		t.Errorf("Dropped = %d, want 2", n)
+	}
+
+	ResetStats()
+	if n := Stats()["limited"].RateLimited; n != 0 {
+		t.Errorf("RateLimited = %d after ResetStats", n)
+	}
+}
@@ -0,0 +1,294 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Record is a log event as sinks receive it, without sentry.Event internals.
+// Logging builds a record, shared by all destinations, destinations with a
+// sentry client convert their copy to a sentry event, see event.
+type Record struct {
+	ID       string
+	Time     time.Time
+	Level    Level
+	Logger   string
+	Msg      string
+	Caller   string                            // file:line of the log call, see SetReportCaller
+	Fields   map[string]interface{}            // fields of the default context
+	Contexts map[string]map[string]interface{} // named contexts, see Cxt
+	Tags     map[string]string
+	Errors   []RecordError // error chain, outermost first
+	Stack    []StackFrame  // stack of the log call, of ERR/FTL without error, see SetAttachStacktrace
+	Extra    map[string]interface{}
+
+	base  *sentry.Event // sentry-only parts, e.g. the request of a recovered panic or the event of CaptureSentryEvent
+	scope *sentry.Scope // of the hub of the log call, applied by destinations with a sentry client only
+}
+
+// RecordError is an error of a Record
+type RecordError struct {
+	Type  string
+	Value string
+	Stack []StackFrame // where the error originated, oldest frame first
+}
+
+// StackFrame is a frame of a stack, oldest frame first
+type StackFrame struct {
+	Function string
+	Module   string
+	File     string
+	Line     int
+	InApp    bool // of the application, not of the standard library or a dependency
+}
+
+// MarshalJSON encodes r as the JSON format does an event, see JSONFormat,
+// without converting it to a sentry event
+func (r Record) MarshalJSON() ([]byte, error) {
+
+	obj := make(map[string]interface{}, len(r.Fields)+len(r.Contexts)+6)
+	for k, v := range r.Fields {
+		obj[k] = v
+	}
+	for name, fields := range r.Contexts {
+		obj[name] = fields
+	}
+
+	obj["time"] = r.Time.UTC().Format(time.RFC3339Nano)
+	obj["level"] = "info"
+	if r.Level >= DEBUG && r.Level <= FATAL {
+		obj["level"] = levelName(sentryLevels[r.Level-1])
+	}
+	obj["msg"] = r.Msg
+
+	if r.Caller != "" {
+		obj["caller"] = r.Caller
+	}
+
+	if len(r.Errors) > 0 {
+		obj["error"] = r.Errors[0].Value
+		obj["error_type"] = r.Errors[0].Type
+		if st := sentryStacktrace(r.Errors[len(r.Errors)-1].Stack); st != nil {
+			obj["stacktrace"] = stackTraceString(st)
+		}
+	}
+
+	return encodeJSON(obj)
+}
+
+// record of an event of sentry-go, e.g. of CaptureSentryEvent, destinations
+// with a sentry client get ev as is
+func sentryRecord(ev *sentry.Event) *Record {
+
+	r := recordOf(ev)
+	r.base = ev
+	return &r
+}
+
+// record of ev, the maps are shared with ev
+func recordOf(ev *sentry.Event) Record {
+
+	r := Record{
+		ID:     string(ev.EventID),
+		Time:   ev.Timestamp,
+		Level:  senlogLevels[ev.Level],
+		Logger: ev.Logger,
+		Msg:    ev.Message,
+		Tags:   ev.Tags,
+		Extra:  ev.Extra,
+	}
+
+	if c, ok := ev.Extra["caller"].(string); ok {
+		r.Caller = c
+	}
+
+	r.setContexts(ev.Contexts)
+
+	for i := len(ev.Exception) - 1; i >= 0; i-- { // sentry lists the outermost error last
+		ex := ev.Exception[i]
+		r.Errors = append(r.Errors, RecordError{Type: ex.Type, Value: ex.Value, Stack: stackFrames(ex.Stacktrace)})
+	}
+
+	for _, th := range ev.Threads {
+		if th.Current {
+			r.Stack = stackFrames(th.Stacktrace)
+		}
+	}
+
+	return r
+}
+
+// sets the fields and named contexts of r from the contexts of an event,
+// sentry's contexts, e.g. os, are left out
+func (r *Record) setContexts(contexts map[string]interface{}) {
+
+	for name, fields := range contexts {
+		m, ok := fields.(map[string]interface{})
+		switch {
+		case !ok || sentryContext(name):
+		case name == "Default Context":
+			r.Fields = m
+		default:
+			if r.Contexts == nil {
+				r.Contexts = make(map[string]map[string]interface{}, len(contexts))
+			}
+			r.Contexts[name] = m
+		}
+	}
+}
+
+func stackFrames(st *sentry.Stacktrace) []StackFrame {
+
+	if st == nil {
+		return nil
+	}
+
+	frames := make([]StackFrame, len(st.Frames))
+	for i, f := range st.Frames {
+		file := f.AbsPath
+		if file == "" {
+			file = f.Filename
+		}
+		frames[i] = StackFrame{Function: f.Function, Module: f.Module, File: file, Line: f.Lineno, InApp: f.InApp}
+	}
+	return frames
+}
+
+// sentry event of r, the maps of r are shared with it. Exceptions and threads
+// of a sentry-go event, see CaptureSentryEvent, keep their details.
+func (r Record) event() *sentry.Event {
+
+	ev := new(sentry.Event)
+	if r.base != nil {
+		ev = cloneEvent(r.base) // the base is shared by all destinations
+	}
+
+	ev.EventID = sentry.EventID(r.ID)
+	ev.Timestamp = r.Time
+	ev.Logger = r.Logger
+	ev.Message = r.Msg
+	ev.Tags = r.Tags
+	ev.Extra = r.Extra
+	if r.Level >= DEBUG && r.Level <= FATAL {
+		ev.Level = sentryLevels[r.Level-1]
+	}
+
+	if ev.Tags == nil { // BeforeSend and event processors can add to them
+		ev.Tags = make(map[string]string)
+	}
+	if r.Caller != "" && r.Extra["caller"] != r.Caller {
+		ev.Extra = make(map[string]interface{}, len(r.Extra)+1)
+		for k, v := range r.Extra {
+			ev.Extra[k] = v
+		}
+		ev.Extra["caller"] = r.Caller
+	} else if ev.Extra == nil {
+		ev.Extra = make(map[string]interface{})
+	}
+
+	contexts := make(map[string]interface{}, len(ev.Contexts)+len(r.Contexts)+1)
+	for name, fields := range ev.Contexts { // sentry contexts of the base, e.g. trace
+		contexts[name] = fields
+	}
+	if r.Fields != nil {
+		contexts["Default Context"] = r.Fields
+	}
+	for name, fields := range r.Contexts {
+		contexts[name] = fields
+	}
+	ev.Contexts = contexts
+
+	if len(ev.Exception) == 0 {
+		for i := len(r.Errors) - 1; i >= 0; i-- {
+			e := r.Errors[i]
+			ev.Exception = append(ev.Exception, sentry.Exception{Type: e.Type, Value: e.Value, Stacktrace: sentryStacktrace(e.Stack)})
+		}
+	}
+
+	if len(ev.Threads) == 0 && len(r.Stack) > 0 {
+		th := sentry.Thread{Stacktrace: sentryStacktrace(r.Stack), Current: true}
+		if len(r.Errors) > 0 { // the errors carry their own stack
+			th.Name = "log site"
+		} else {
+			th.Crashed = r.Level == FATAL
+		}
+		ev.Threads = []sentry.Thread{th}
+	}
+
+	return ev
+}
+
+// sentry-only parts of r, see event
+func (r *Record) sentryPart() *sentry.Event {
+
+	if r.base == nil {
+		r.base = new(sentry.Event)
+	}
+	return r.base
+}
+
+// audit records and records of debug sampled requests are logged regardless
+// of level, like such events, see levelExempt
+func (r *Record) levelExempt() bool {
+	return r.Logger == auditLoggerName || r.Tags[debugSampledTag] == "true"
+}
+
+// deep copy of r, so a destination's hub, BeforeSend, event processors or sink
+// can change it without other destinations seeing it. Maps and slices of field
+// values are copied, other values, e.g. pointers, are shared. The base is
+// copied by event.
+func cloneRecord(r *Record) *Record {
+
+	c := *r
+	c.Fields, _ = cloneValue(r.Fields).(map[string]interface{})
+	c.Extra, _ = cloneValue(r.Extra).(map[string]interface{})
+
+	if r.Contexts != nil {
+		c.Contexts = make(map[string]map[string]interface{}, len(r.Contexts))
+		for name, fields := range r.Contexts {
+			c.Contexts[name], _ = cloneValue(fields).(map[string]interface{})
+		}
+	}
+
+	if r.Tags != nil {
+		c.Tags = make(map[string]string, len(r.Tags))
+		for k, v := range r.Tags {
+			c.Tags[k] = v
+		}
+	}
+
+	if r.Errors != nil {
+		c.Errors = make([]RecordError, len(r.Errors))
+		for i, e := range r.Errors {
+			e.Stack = append([]StackFrame(nil), e.Stack...)
+			c.Errors[i] = e
+		}
+	}
+	c.Stack = append([]StackFrame(nil), r.Stack...)
+
+	return &c
+}
+
+func sentryStacktrace(frames []StackFrame) *sentry.Stacktrace {
+
+	if len(frames) == 0 {
+		return nil
+	}
+
+	st := &sentry.Stacktrace{Frames: make([]sentry.Frame, len(frames))}
+	for i, f := range frames {
+		st.Frames[i] = sentry.Frame{Function: f.Function, Module: f.Module, AbsPath: f.File, Lineno: f.Line, InApp: f.InApp}
+	}
+	return st
+}
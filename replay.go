@@ -0,0 +1,62 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// StaleEvents selects what replays do with events older than the max age
+type StaleEvents int32
+
+const (
+	DropStale StaleEvents = iota // stale events are discarded
+	TagStale                     // stale events are sent tagged senlog.stale=true
+)
+
+type replayPolicy struct {
+	maxAge time.Duration
+	stale  StaleEvents
+}
+
+// SetReplayMaxAge sets the max age of events replayed from crash markers and
+// outboxes, e.g. after a long outage. Older events are dropped or tagged as
+// stale, so they don't show up as current issues. Replayed events always keep
+// their original timestamp. 0 replays events of any age, the default.
+func SetReplayMaxAge(maxAge time.Duration, stale StaleEvents) {
+	updateSettings(func(s *settings) { s.replay = replayPolicy{maxAge: maxAge, stale: stale} })
+}
+
+// applies the replay policy to ev, false if it must not be sent
+func replayable(ev *sentry.Event) bool {
+
+	p := loadSettings().replay
+	age := now().Sub(ev.Timestamp)
+
+	if p.maxAge <= 0 || ev.Timestamp.IsZero() || age <= p.maxAge {
+		return true
+	}
+
+	if p.stale == DropStale {
+		diagnose(fmt.Errorf("dropped stale event %s replayed %s after it occurred", ev.EventID, age.Round(time.Second)))
+		return false
+	}
+
+	if ev.Tags == nil {
+		ev.Tags = make(map[string]string)
+	}
+	ev.Tags["senlog.stale"] = "true"
+	ev.Tags["senlog.age"] = age.Round(time.Second).String()
+	return true
+}
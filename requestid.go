@@ -0,0 +1,64 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader is read and echoed by RequestIDMiddleware
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware correlates the events of a request: it takes the request
+// ID of the X-Request-ID header, or generates one if it is missing or invalid,
+// echoes it in the response header and carries it in the request context as
+// field request_id, see WithContext. Events logged with the Ctx variants, e.g.
+// INFCtx(r.Context(), ...), get the field.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = string(newEventID())
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = WithContext(ctx, Set("request_id", id))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestID returns the request ID of ctx set by RequestIDMiddleware, empty if
+// there is none. Pass it on to called services in the X-Request-ID header.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// request IDs from clients are logged, only short printable ASCII is accepted
+func validRequestID(id string) bool {
+
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sampling holds the sentry-go sampling options of a destination
+type Sampling struct {
+	SampleRate       float64 // share of events sent, 0 < rate <= 1
+	TracesSampleRate float64 // share of transactions sent, 0 <= rate <= 1
+	EnableTracing    bool    // send transactions, requires TracesSampleRate > 0, off removes a TracesSampler
+}
+
+// SetSampling validates s and applies it to a destination. Events are sampled
+// before the transport, so a SampleRate below 1 also drops console and file
+// lines of the destination, a warning is logged then. Audit events and, with
+// priority lanes, ERROR and FATAL events are never sampled out, see
+// SetPriorityLanes.
+func SetSampling(destinationKey string, s Sampling) error {
+
+	d, exists := lookup(destinationKey)
+	if !exists {
+		return errors.New("Destination doesn't exist: " + destinationKey)
+	}
+
+	if err := s.validate(); err != nil {
+		return err
+	}
+
+	options := d.hub.Client().Options()
+
+	if s.TracesSampleRate != 0 && options.TracesSampler != nil {
+		return errors.New("sampling: TracesSampleRate and the destination's TracesSampler are mutually exclusive")
+	}
+	options.TracesSampleRate = s.TracesSampleRate
+	if !s.EnableTracing {
+		options.TracesSampler = nil
+	}
+
+	if err := d.rebind(options); err != nil {
+		return err
+	}
+	d.setSampleRate(s.SampleRate)
+
+	if _, ok := options.Transport.(*SentryTransport); !ok && s.SampleRate < 1 {
+		notice("sample rate %v drops log lines of destination %q, which is not sentry", s.SampleRate, destinationKey)
+	}
+
+	return nil
+}
+
+func (s Sampling) validate() error {
+
+	switch {
+	case s.SampleRate <= 0 || s.SampleRate > 1:
+		return fmt.Errorf("sampling: SampleRate %v is not in (0, 1]", s.SampleRate)
+	case s.TracesSampleRate < 0 || s.TracesSampleRate > 1:
+		return fmt.Errorf("sampling: TracesSampleRate %v is not in [0, 1]", s.TracesSampleRate)
+	case s.EnableTracing && s.TracesSampleRate == 0:
+		return errors.New("sampling: EnableTracing requires a TracesSampleRate above 0")
+	case !s.EnableTracing && s.TracesSampleRate > 0:
+		return errors.New("sampling: TracesSampleRate is set but EnableTracing is off")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// queues events until flushed, and like sentry.HTTPTransport drops the queue
+// when configured again
+type queueingTransport struct {
+	mu        sync.Mutex
+	queue     []string
+	delivered []string
+}
+
+func (t *queueingTransport) Configure(sentry.ClientOptions) {
+	t.mu.Lock()
+	t.queue = nil
+	t.mu.Unlock()
+}
+
+func (t *queueingTransport) SendEvent(ev *sentry.Event) {
+	t.mu.Lock()
+	t.queue = append(t.queue, ev.Message)
+	t.mu.Unlock()
+}
+
+func (t *queueingTransport) Flush(time.Duration) bool {
+	t.mu.Lock()
+	t.delivered = append(t.delivered, t.queue...)
+	t.queue = nil
+	t.mu.Unlock()
+	return true
+}
+
+func TestNewClientKeepsQueuedEvents(t *testing.T) {
+
+	quiet(t)
+	queueing := new(queueingTransport)
+	addTestDestination(t, "queueing", sentry.ClientOptions{Transport: queueing})
+
+	INF("queued")
+	if err := SetSampling("queueing", Sampling{SampleRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setTransport("queueing", new(queueingTransport)); err != nil {
+		t.Fatal(err)
+	}
+
+	queueing.mu.Lock()
+	defer queueing.mu.Unlock()
+	if !contains(queueing.delivered, "queued") {
+		t.Errorf("event queued before the client changed was dropped, delivered %q", queueing.delivered)
+	}
+}
+
+func TestTracingOffRemovesTracesSampler(t *testing.T) {
+
+	quiet(t)
+	addTestDestination(t, "traced", sentry.ClientOptions{
+		Transport:     newRecordingTransport(DEBUG),
+		TracesSampler: sentry.UniformTracesSampler(1),
+	})
+
+	if err := SetSampling("traced", Sampling{SampleRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, _ := lookup("traced")
+	if options := d.hub.Client().Options(); options.TracesSampler != nil || options.TracesSampleRate != 0 {
+		t.Error("transactions still sampled with EnableTracing off")
+	}
+}
@@ -0,0 +1,64 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sanitize escapes control characters of user supplied text, so a message or
+// field can't forge log lines or change the terminal with escape sequences:
+// ESC becomes the text \x1b, invalid UTF-8 the replacement character.
+// Tabs are kept, lines after a newline are indented by a tab so they can't pass
+// for a line of their own.
+func Sanitize(s string) string {
+
+	if !needsSanitize(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r == '\n' && i+size < len(s):
+			b.WriteString("\n\t")
+		case r == '\n' || r == '\t':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", r)
+		case r >= 0x80 && r <= 0x9f: // C1 controls, e.g. the single character CSI
+			fmt.Fprintf(&b, "\\u%04x", r)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+
+	return b.String()
+}
+
+// fast path for the common clean text
+func needsSanitize(s string) bool {
+
+	for _, r := range s {
+		if (r < 0x20 && r != '\t') || (r >= 0x7f && r <= 0x9f) || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
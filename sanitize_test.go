@@ -0,0 +1,31 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "testing"
+
+func TestSanitizeCantForgeLines(t *testing.T) {
+
+	tests := []struct{ in, want string }{
+		{"clean text", "clean text"},
+		{"a\tb", "a\tb"},
+		{"login failed\n2022/05/01 12:00:00 INF admin logged in", "login failed\n\t2022/05/01 12:00:00 INF admin logged in"},
+		{"a\r\nb", `a\x0d` + "\n\tb"},
+		{"ends with newline\n", "ends with newline\n"},
+		{"\x1b[2Jcleared", `\x1b[2Jcleared`},
+	}
+
+	for _, tt := range tests {
+		if got := Sanitize(tt.in); got != tt.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FieldType is the type a Schema allows for a field
+type FieldType int
+
+const (
+	AnyType FieldType = iota
+	StringType
+	NumberType // any int, uint or float
+	BoolType
+	TimeType     // time.Time
+	DurationType // time.Duration
+)
+
+// Schema of a named context, e.g. the "http" context must always have a
+// numeric "status"
+type Schema struct {
+	Required []string             // keys every event with the context must set
+	Types    map[string]FieldType // allowed type per key, keys not listed may have any type
+}
+
+var (
+	schemasMu sync.RWMutex
+	schemas   = make(map[string]Schema)
+)
+
+// RegisterSchema validates the fields of every event having the named context
+// against schema. Violations are logged as WARN, in strict mode they panic.
+func RegisterSchema(cxtName string, schema Schema) {
+
+	schemasMu.Lock()
+	schemas[cxtName] = schema
+	schemasMu.Unlock()
+}
+
+// UnregisterSchema removes the schema of a named context
+func UnregisterSchema(cxtName string) {
+
+	schemasMu.Lock()
+	delete(schemas, cxtName)
+	schemasMu.Unlock()
+}
+
+// validate the contexts of an event, report violations
+func checkSchemas(contexts map[string]interface{}) {
+
+	schemasMu.RLock()
+	if len(schemas) == 0 {
+		schemasMu.RUnlock()
+		return
+	}
+
+	var violations []string
+	for name, fields := range contexts {
+		schema, ok := schemas[name]
+		if !ok || strings.HasPrefix(name, loggerName) { // never validate senlog's own contexts
+			continue
+		}
+		group, ok := fields.(map[string]interface{})
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s: not a context but %T", name, fields))
+			continue
+		}
+		violations = append(violations, schema.check(name, group)...)
+	}
+	schemasMu.RUnlock()
+
+	if len(violations) == 0 {
+		return
+	}
+
+	sort.Strings(violations)
+	if strictMode() {
+		panic("senlog: context schema violated: " + strings.Join(violations, "; "))
+	}
+	Cxt(loggerName+".schema").Set("violations", violations).WRN("Context schema violated")
+}
+
+func (s Schema) check(name string, fields map[string]interface{}) (violations []string) {
+
+	for _, k := range s.Required {
+		if _, ok := fields[k]; !ok {
+			violations = append(violations, fmt.Sprintf("%s: missing required field %q", name, k))
+		}
+	}
+
+	for k, v := range fields {
+		t, ok := s.Types[k]
+		if ok && !t.matches(v) {
+			violations = append(violations, fmt.Sprintf("%s: field %q has type %T", name, k, v))
+		}
+	}
+
+	return violations
+}
+
+func (t FieldType) matches(v interface{}) bool {
+
+	if p, ok := v.(piiValue); ok {
+		v = p.v
+	}
+
+	switch v.(type) {
+	case time.Time:
+		return t == AnyType || t == TimeType
+	case time.Duration:
+		return t == AnyType || t == DurationType || t == NumberType
+	}
+
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String:
+		// SetDur and SetTime store their values already formatted
+		s := reflect.ValueOf(v).String()
+		switch t {
+		case DurationType:
+			_, err := time.ParseDuration(s)
+			return err == nil
+		case TimeType:
+			_, err := time.Parse(time.RFC3339Nano, s)
+			return err == nil
+		}
+		return t == AnyType || t == StringType
+	case reflect.Bool:
+		return t == AnyType || t == BoolType
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t == AnyType || t == NumberType
+	}
+
+	return t == AnyType
+}
@@ -0,0 +1,64 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+func TestSchemaOfAContextNotAMap(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	RegisterSchema("order", Schema{Required: []string{"id"}})
+	defer UnregisterSchema("order")
+
+	checkSchemas(map[string]interface{}{"order": "not a map"})
+
+	if !contains(rec.Messages(), "Context schema violated") {
+		t.Errorf("got %q, want a schema violation", rec.Messages())
+	}
+}
+
+func TestSetLogLevelOfTransportWithoutLevel(t *testing.T) {
+
+	quiet(t)
+	addTestDestination(t, "plain", sentry.ClientOptions{Transport: new(plainTransport)})
+
+	SetLogLevel("plain", ERROR) // must not panic
+}
+
+func TestSchemaOfTypedSetters(t *testing.T) {
+
+	quiet(t)
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+	RegisterSchema("job", Schema{Types: map[string]FieldType{"took": DurationType, "at": TimeType}})
+	defer UnregisterSchema("job")
+	SetStrictMode(true)
+	defer SetStrictMode(false)
+
+	Cxt("job").SetDur("took", 1200*time.Millisecond).SetTime("at", time.Now()).INF("Job done") // must not panic
+
+	if contains(rec.Messages(), "Context schema violated") {
+		t.Errorf("got %q, want no schema violation", rec.Messages())
+	}
+
+	SetStrictMode(false)
+	Cxt("job").SetStr("took", "long").SetStr("at", "yesterday").INF("Job done")
+	if !contains(rec.Messages(), "Context schema violated") {
+		t.Errorf("got %q, want a schema violation", rec.Messages())
+	}
+}
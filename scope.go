@@ -0,0 +1,67 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// sentry-go scope of the hub in ctx, e.g. set by sentryhttp, or of the current
+// hub, see applySentryScope
+func sentryScope(ctx context.Context) *sentry.Scope {
+
+	hub := sentry.CurrentHub()
+	if ctx != nil {
+		if h := sentry.GetHubFromContext(ctx); h != nil {
+			hub = h
+		}
+	}
+	return hub.Scope()
+}
+
+// applies the sentry-go scope of a log call to its event, so tags, user,
+// breadcrumbs and contexts set with sentry.ConfigureScope show up in the events
+// of destinations with a sentry client. Level and fields of the log call win
+// over the scope. Each destination hub applies its own scope on send. Returns
+// nil if an event processor of the scope dropped the event.
+func applySentryScope(scope *sentry.Scope, ev *sentry.Event) *sentry.Event {
+
+	if scope == nil {
+		return ev
+	}
+
+	level := ev.Level
+	contexts := make(map[string]interface{}, len(ev.Contexts))
+	for k, v := range ev.Contexts {
+		contexts[k] = v
+	}
+	tags := make(map[string]string, len(ev.Tags))
+	for k, v := range ev.Tags {
+		tags[k] = v
+	}
+
+	ev = scope.ApplyToEvent(ev, nil)
+	if ev == nil {
+		return nil
+	}
+
+	ev.Level = level
+	for k, v := range contexts {
+		ev.Contexts[k] = v
+	}
+	for k, v := range tags {
+		ev.Tags[k] = v
+	}
+
+	return ev
+}
@@ -0,0 +1,122 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package sencron connects robfig/cron schedulers to senlog: cron's internal
+// logging goes through senlog and wrapped jobs get start/finish logging,
+// panic capture and optional cron monitor check-ins.
+package sencron
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/getsentry/sentry-go"
+	"github.com/robfig/cron/v3"
+)
+
+// Logger implements cron.Logger, use it with cron.WithLogger(sencron.Logger{})
+type Logger struct{}
+
+// cron's info messages (schedule, wake, run) are verbose, they are logged as DEBUG
+func (Logger) Info(msg string, keysAndValues ...interface{}) {
+	context(keysAndValues).DBG(msg)
+}
+
+func (Logger) Error(err error, msg string, keysAndValues ...interface{}) {
+	context(keysAndValues).ERR(err, msg)
+}
+
+func context(keysAndValues []interface{}) *senlog.Context {
+
+	x := senlog.Cxt("cron")
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		x = x.Set(fmt.Sprint(keysAndValues[i]), keysAndValues[i+1])
+	}
+	return x
+}
+
+// check-in statuses of a cron monitor
+const (
+	InProgress = "in_progress"
+	OK         = "ok"
+	Error      = "error"
+)
+
+// CheckIn reports the status of a job run to a cron monitor
+type CheckIn func(job string, status string)
+
+// Wrap returns a cron.JobWrapper running jobs through senlog.Job under the given
+// name, checkIn may be nil:
+//
+//	c.AddJob("@hourly", cron.NewChain(sencron.Wrap("cleanup", nil)).Then(job))
+func Wrap(name string, checkIn CheckIn) cron.JobWrapper {
+
+	return func(j cron.Job) cron.Job {
+		return cron.FuncJob(func() {
+
+			if checkIn != nil {
+				checkIn(name, InProgress)
+			}
+
+			err := senlog.Job(name, func(*senlog.Context) error {
+				j.Run()
+				return nil
+			})
+
+			if checkIn != nil {
+				if err != nil {
+					checkIn(name, Error)
+				} else {
+					checkIn(name, OK)
+				}
+			}
+		})
+	}
+}
+
+// SentryCheckIn returns a CheckIn using Sentry's HTTP check-in endpoint of the
+// project in dsn, the job name is used as the monitor slug. Failed check-ins
+// are logged as warnings.
+func SentryCheckIn(dsn string) (CheckIn, error) {
+
+	d, err := sentry.NewDsn(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// the store endpoint is <scheme>://<host>/api/<project>/store/
+	base := strings.TrimSuffix(d.StoreAPIURL().String(), "store/") + "cron/"
+
+	u, _ := url.Parse(dsn) // valid, NewDsn parsed it
+	key := u.User.Username()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	return func(job string, status string) {
+
+		endpoint := base + url.PathEscape(job) + "/" + key + "/?status=" + url.QueryEscape(status)
+
+		resp, err := client.Get(endpoint)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				err = errors.New(strings.TrimSpace(resp.Status))
+			}
+		}
+		if err != nil {
+			senlog.Cxt("cron").Set("job", job).Set("status", status).Set("error", err.Error()).WRN("Cron check-in failed")
+		}
+	}, nil
+}
@@ -0,0 +1,50 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package senkafka routes Kafka client logs through senlog.
+package senkafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ejazmughal/senlog"
+)
+
+// SaramaLogger implements sarama.StdLogger without importing sarama, use it as
+// sarama.Logger = senkafka.SaramaLogger{}. Sarama logs client internals
+// (metadata refreshes, rebalances, retries) through it, they are logged as
+// DEBUG, lines mentioning an error as WARN.
+type SaramaLogger struct{}
+
+func (l SaramaLogger) Print(v ...interface{}) {
+	l.log(fmt.Sprint(v...))
+}
+
+func (l SaramaLogger) Printf(format string, v ...interface{}) {
+	l.log(fmt.Sprintf(format, v...))
+}
+
+func (l SaramaLogger) Println(v ...interface{}) {
+	l.log(fmt.Sprintln(v...))
+}
+
+func (SaramaLogger) log(msg string) {
+
+	msg = strings.TrimSpace(msg)
+	x := senlog.Cxt("kafka").Set("client", "sarama")
+
+	lower := strings.ToLower(msg)
+	if strings.Contains(lower, "error") || strings.Contains(lower, "failed") {
+		x.WRN(msg)
+	} else {
+		x.DBG(msg)
+	}
+}
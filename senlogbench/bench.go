@@ -0,0 +1,295 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package senlogbench measures senlog with realistic field mixes, for
+// comparisons with other loggers and as regression gate in CI:
+//
+//	func BenchmarkCapture(b *testing.B) { senlogbench.Capture(b, senlogbench.Medium) }
+//
+//	results := senlogbench.RunAll(senlog.NewTransport(io.Discard))
+//	baseline, _ := senlogbench.LoadBaseline("bench.json")
+//	if err := senlogbench.Gate(baseline, results, 0.1); err != nil { ... }
+//
+// The module in compare benchmarks the same mixes with zap and zerolog.
+package senlogbench
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/getsentry/sentry-go"
+)
+
+// Fields is a field mix of the benchmarked events
+type Fields struct {
+	Name   string
+	Msg    string
+	Values map[string]interface{}
+}
+
+// field mixes of typical service logs
+var (
+	Small = Fields{Name: "small", Msg: "request served", Values: map[string]interface{}{
+		"status": 200,
+	}}
+	Medium = Fields{Name: "medium", Msg: "request served", Values: map[string]interface{}{
+		"method":   "GET",
+		"path":     "/api/v1/users/42",
+		"status":   200,
+		"bytes":    5120,
+		"duration": 12.5,
+		"user_id":  "u-42",
+	}}
+	Large = Fields{Name: "large", Msg: "request served", Values: map[string]interface{}{
+		"method":     "POST",
+		"path":       "/api/v1/orders",
+		"status":     201,
+		"bytes":      20480,
+		"duration":   48.25,
+		"user_id":    "u-42",
+		"request_id": "0af7651916cd43dd8448eb211c80319c",
+		"remote":     "203.0.113.7:52144",
+		"agent":      "Mozilla/5.0 (X11; Linux x86_64)",
+		"cached":     false,
+		"retries":    2,
+		"items":      []string{"sku-1", "sku-2", "sku-3"},
+		"headers":    map[string]string{"Accept": "application/json", "Content-Type": "application/json"},
+		"started":    time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+	}}
+)
+
+// Mixes are all field mixes, smallest first
+var Mixes = []Fields{Small, Medium, Large}
+
+const destinationKey = "senlogbench"
+
+var mu sync.Mutex // one benchmark destination at a time
+
+// Capture measures the capture path, logging an INF event with the fields to
+// a transport discarding it. Other destinations don't receive the events.
+func Capture(b *testing.B, fields Fields) {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := senlog.AddDestination(destinationKey, sentry.ClientOptions{Transport: discard{}}); err != nil {
+		b.Fatal(err)
+	}
+	defer senlog.RemoveDestination(destinationKey)
+
+	others := others()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		x := senlog.Except(others...)
+		for k, v := range fields.Values {
+			x = x.Set(k, v)
+		}
+		x.INF(fields.Msg)
+	}
+	b.StopTimer()
+	reportRate(b, time.Since(start))
+}
+
+// Transport measures t sending an event captured with the fields
+func Transport(b *testing.B, t sentry.Transport, fields Fields) {
+
+	ev, err := Event(fields)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		t.SendEvent(ev)
+	}
+	b.StopTimer()
+	elapsed := time.Since(start)
+	t.Flush(time.Second)
+	reportRate(b, elapsed)
+}
+
+// Event returns the event senlog captures for an INF with the fields, a new
+// one each call. With parallel dispatch it waits for the event to be sent.
+func Event(fields Fields) (*sentry.Event, error) {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	rec := &recorder{events: make(chan *sentry.Event, 1)}
+	if err := senlog.AddDestination(destinationKey, sentry.ClientOptions{Transport: rec}); err != nil {
+		return nil, err
+	}
+	defer senlog.RemoveDestination(destinationKey)
+
+	atomic.StoreInt32(&rec.recording, 1) // after the setup notices
+	x := senlog.Except(others()...)
+	for k, v := range fields.Values {
+		x = x.Set(k, v)
+	}
+	x.INF(fields.Msg)
+
+	select {
+	case ev := <-rec.events:
+		return ev, nil
+	case <-time.After(time.Second):
+		return nil, errors.New("senlogbench: no event captured, INFO is not enabled")
+	}
+}
+
+// keys of the destinations but the benchmark's
+func others() []string {
+
+	keys := make([]string, 0)
+	for key := range senlog.Stats() {
+		if key != destinationKey {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func reportRate(b *testing.B, elapsed time.Duration) {
+
+	if s := elapsed.Seconds(); s > 0 {
+		b.ReportMetric(float64(b.N)/s, "events/s")
+	}
+}
+
+// Result is the outcome of one benchmark
+type Result struct {
+	NsPerOp      float64
+	AllocsPerOp  int64
+	BytesPerOp   int64
+	EventsPerSec float64
+}
+
+// Run runs a benchmark outside of go test
+func Run(fn func(b *testing.B)) Result {
+
+	r := testing.Benchmark(fn)
+	res := Result{
+		NsPerOp:     float64(r.NsPerOp()),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+	}
+	if r.T > 0 {
+		res.EventsPerSec = float64(r.N) / r.T.Seconds()
+	}
+	return res
+}
+
+// RunAll runs the capture benchmark and a benchmark of every transport for
+// all field mixes, results are named "capture/<mix>" and "<transport
+// index>/<mix>"
+func RunAll(transports ...sentry.Transport) map[string]Result {
+
+	results := make(map[string]Result)
+	for _, fields := range Mixes {
+		fields := fields
+		results["capture/"+fields.Name] = Run(func(b *testing.B) { Capture(b, fields) })
+		for i, t := range transports {
+			t := t
+			results[fmt.Sprintf("%d/%s", i, fields.Name)] = Run(func(b *testing.B) { Transport(b, t, fields) })
+		}
+	}
+	return results
+}
+
+// LoadBaseline reads results written by SaveBaseline
+func LoadBaseline(file string) (map[string]Result, error) {
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var results map[string]Result
+	return results, json.Unmarshal(b, &results)
+}
+
+// SaveBaseline writes results as JSON, to gate later runs against
+func SaveBaseline(file string, results map[string]Result) error {
+
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(b, '\n'), 0644)
+}
+
+// Gate fails if a result regressed against its baseline by more than
+// tolerance, e.g. 0.1 allows 10% more ns/op and allocs/op. Results without
+// baseline pass.
+func Gate(baseline, results map[string]Result, tolerance float64) error {
+
+	var regressions []string
+	for name, r := range results {
+		base, ok := baseline[name]
+		if !ok {
+			continue
+		}
+		if base.NsPerOp > 0 && r.NsPerOp > base.NsPerOp*(1+tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s: %.0f ns/op, baseline %.0f", name, r.NsPerOp, base.NsPerOp))
+		}
+		if float64(r.AllocsPerOp) > float64(base.AllocsPerOp)*(1+tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s: %d allocs/op, baseline %d", name, r.AllocsPerOp, base.AllocsPerOp))
+		}
+	}
+
+	if len(regressions) == 0 {
+		return nil
+	}
+	sort.Strings(regressions)
+	return errors.New("senlogbench: regressions:\n" + strings.Join(regressions, "\n"))
+}
+
+// transport discarding events
+type discard struct{}
+
+func (discard) Configure(sentry.ClientOptions) {}
+func (discard) SendEvent(*sentry.Event)        {}
+func (discard) Flush(time.Duration) bool       { return true }
+
+// transport handing the first event sent while recording to events, it may
+// be called by a dispatch goroutine
+type recorder struct {
+	recording int32 // 1 while recording
+	events    chan *sentry.Event
+}
+
+func (r *recorder) Configure(sentry.ClientOptions) {}
+
+func (r *recorder) SendEvent(ev *sentry.Event) {
+
+	if atomic.LoadInt32(&r.recording) == 0 {
+		return
+	}
+	select {
+	case r.events <- ev:
+	default: // not the first
+	}
+}
+
+func (r *recorder) Flush(time.Duration) bool {
+	return true
+}
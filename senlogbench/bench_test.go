@@ -0,0 +1,33 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlogbench
+
+import (
+	"testing"
+
+	"github.com/ejazmughal/senlog"
+)
+
+func TestEventWithParallelDispatch(t *testing.T) {
+
+	senlog.SetParallelDispatch(16)
+	defer senlog.SetParallelDispatch(0)
+
+	for _, fields := range Mixes {
+		ev, err := Event(fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Message != fields.Msg {
+			t.Errorf("%s: captured %q, want %q", fields.Name, ev.Message, fields.Msg)
+		}
+	}
+}
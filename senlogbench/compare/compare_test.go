@@ -0,0 +1,77 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package compare
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/ejazmughal/senlog/senlogbench"
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func BenchmarkSenlog(b *testing.B) {
+
+	if err := senlog.AddDestination("compare", sentry.ClientOptions{Transport: senlog.NewTransport(io.Discard, senlog.WithFormat(senlog.JSONFormat))}); err != nil {
+		b.Fatal(err)
+	}
+	defer senlog.RemoveDestination("compare")
+
+	for _, fields := range senlogbench.Mixes {
+		b.Run(fields.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				x := senlog.Except("console")
+				for k, v := range fields.Values {
+					x = x.Set(k, v)
+				}
+				x.INF(fields.Msg)
+			}
+		})
+	}
+}
+
+func BenchmarkZap(b *testing.B) {
+
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zap.InfoLevel)
+	logger := zap.New(core)
+
+	for _, fields := range senlogbench.Mixes {
+		b.Run(fields.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				zf := make([]zap.Field, 0, len(fields.Values))
+				for k, v := range fields.Values {
+					zf = append(zf, zap.Any(k, v))
+				}
+				logger.Info(fields.Msg, zf...)
+			}
+		})
+	}
+}
+
+func BenchmarkZerolog(b *testing.B) {
+
+	logger := zerolog.New(io.Discard).With().Timestamp().Logger()
+
+	for _, fields := range senlogbench.Mixes {
+		b.Run(fields.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				logger.Info().Fields(fields.Values).Msg(fields.Msg)
+			}
+		})
+	}
+}
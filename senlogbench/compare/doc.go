@@ -0,0 +1,17 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package compare benchmarks senlog against zap and zerolog, each writing the
+// field mixes of senlogbench as JSON lines to io.Discard. It is a module of its
+// own, so senlog doesn't depend on the other loggers:
+//
+//	cd senlogbench/compare
+//	go test -bench . -benchmem
+package compare
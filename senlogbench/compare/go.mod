@@ -0,0 +1,20 @@
+module github.com/ejazmughal/senlog/senlogbench/compare
+
+go 1.18
+
+require (
+	github.com/ejazmughal/senlog v0.0.0
+	github.com/getsentry/sentry-go v0.13.0
+	github.com/rs/zerolog v1.28.0
+	go.uber.org/zap v1.21.0
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.12 // indirect
+	github.com/mattn/go-isatty v0.0.14 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	golang.org/x/sys v0.0.0-20211007075335-d3039528d8ac // indirect
+)
+
+replace github.com/ejazmughal/senlog => ../..
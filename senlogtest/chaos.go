@@ -0,0 +1,186 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlogtest
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/getsentry/sentry-go"
+)
+
+// Chaos are the faults injected by a ChaosTransport or ChaosRoundTripper
+type Chaos struct {
+	Latency  time.Duration // added to every send
+	Jitter   time.Duration // random latency up to Jitter added to Latency
+	FailRate float64       // share of sends failing, 0 <= rate <= 1
+	Seed     int64         // seed of the random faults, runs with the same seed fail alike
+}
+
+// injects the faults of a Chaos
+type faults struct {
+	Chaos
+	down   int32 // 1 during an outage, every send fails
+	failed uint64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newFaults(c Chaos) *faults {
+	return &faults{Chaos: c, rand: rand.New(rand.NewSource(c.Seed))}
+}
+
+// waits the latency of a send and tells whether it fails
+func (f *faults) inject() bool {
+
+	f.mu.Lock()
+	delay := f.Latency
+	if f.Jitter > 0 {
+		delay += time.Duration(f.rand.Int63n(int64(f.Jitter)))
+	}
+	fail := f.rand.Float64() < f.FailRate
+	f.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	if fail || atomic.LoadInt32(&f.down) == 1 {
+		atomic.AddUint64(&f.failed, 1)
+		return true
+	}
+	return false
+}
+
+// SetOutage fails every send until it is called with false
+func (f *faults) SetOutage(down bool) {
+	if down {
+		atomic.StoreInt32(&f.down, 1)
+	} else {
+		atomic.StoreInt32(&f.down, 0)
+	}
+}
+
+// Failed returns the number of sends failed on purpose
+func (f *faults) Failed() uint64 {
+	return atomic.LoadUint64(&f.failed)
+}
+
+// ChaosTransport decorates a transport with latency and failures, to verify
+// how an application's logging behaves under backend outages. Latency beyond
+// a destination's send timeout counts as failed send and trips its circuit
+// breaker, see senlog.SetSendTimeout. Failed events are lost, like events of
+// a transport whose backend is down.
+//
+//	chaos := senlogtest.NewChaosTransport(senlog.NewSentryTransport(senlog.INFO), senlogtest.Chaos{Latency: time.Second})
+//	senlog.AddDestination("sentry", sentry.ClientOptions{Dsn: dsn, Transport: chaos})
+//	chaos.SetOutage(true)
+type ChaosTransport struct {
+	*faults
+	transport sentry.Transport
+}
+
+func NewChaosTransport(t sentry.Transport, c Chaos) *ChaosTransport {
+	return &ChaosTransport{faults: newFaults(c), transport: t}
+}
+
+func (t *ChaosTransport) Configure(options sentry.ClientOptions) {
+	t.transport.Configure(options)
+}
+
+func (t *ChaosTransport) SendEvent(ev *sentry.Event) {
+
+	if t.inject() {
+		return
+	}
+	t.transport.SendEvent(ev)
+}
+
+func (t *ChaosTransport) Flush(timeout time.Duration) bool {
+	return t.transport.Flush(timeout)
+}
+
+// Close closes the decorated transport if it is an io.Closer
+func (t *ChaosTransport) Close() error {
+
+	if c, ok := t.transport.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// SetLogLevel and MinLogLevel pass the level of the decorated transport
+func (t *ChaosTransport) SetLogLevel(level senlog.Level) {
+
+	if l, ok := t.transport.(senlog.LeveledLogger); ok {
+		l.SetLogLevel(level)
+	}
+}
+
+func (t *ChaosTransport) MinLogLevel() senlog.Level {
+
+	if l, ok := t.transport.(senlog.LeveledLogger); ok {
+		return l.MinLogLevel()
+	}
+	return 0 // logs all levels
+}
+
+// ChaosRoundTripper injects faults into the HTTP requests of a transport, e.g.
+// the sentry transport's options.HTTPTransport. Failed requests alternate
+// between network errors and 503 responses, so retries, outboxes and error
+// handling of the transport are exercised.
+type ChaosRoundTripper struct {
+	*faults
+	transport http.RoundTripper
+	fails     uint64
+}
+
+// NewChaosRoundTripper decorates rt, nil decorates http.DefaultTransport
+func NewChaosRoundTripper(rt http.RoundTripper, c Chaos) *ChaosRoundTripper {
+
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &ChaosRoundTripper{faults: newFaults(c), transport: rt}
+}
+
+func (rt *ChaosRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	if !rt.inject() {
+		return rt.transport.RoundTrip(req)
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	if atomic.AddUint64(&rt.fails, 1)%2 == 1 {
+		return nil, errors.New("senlogtest: injected network error")
+	}
+	return &http.Response{
+		Status:     "503 Service Unavailable",
+		StatusCode: http.StatusServiceUnavailable,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader("senlogtest: injected failure")),
+		Request:    req,
+	}, nil
+}
@@ -0,0 +1,43 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlogtest
+
+import (
+	"sync"
+
+	"github.com/ejazmughal/senlog"
+)
+
+// Drops returns the events logged by fn that were not sent to a destination,
+// annotated with why, see senlog.SetFilterDebug. It replaces the filter debug
+// function meanwhile and restores it after.
+func Drops(fn func()) []senlog.Drop {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var (
+		dropsMu sync.Mutex
+		drops   []senlog.Drop
+	)
+	defer senlog.SetFilterDebug(senlog.FilterDebug())
+	senlog.SetFilterDebug(func(d senlog.Drop) {
+		dropsMu.Lock()
+		drops = append(drops, d)
+		dropsMu.Unlock()
+	})
+
+	fn()
+
+	dropsMu.Lock()
+	defer dropsMu.Unlock()
+	return drops
+}
@@ -0,0 +1,37 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlogtest
+
+import (
+	"testing"
+
+	"github.com/ejazmughal/senlog"
+)
+
+func TestDropsRestoresTheFilterDebugHook(t *testing.T) {
+
+	var appDrops int
+	senlog.SetFilterDebug(func(senlog.Drop) { appDrops++ })
+	defer senlog.SetFilterDebug(nil)
+
+	drops := Drops(func() { senlog.Except("console").INF("dropped by route") })
+	if len(drops) == 0 {
+		t.Fatal("Drops reported no drop")
+	}
+	if appDrops != 0 {
+		t.Errorf("the application's hook got %d drops while Drops ran", appDrops)
+	}
+
+	senlog.Except("console").INF("dropped by route")
+	if appDrops == 0 {
+		t.Error("the application's hook was not restored")
+	}
+}
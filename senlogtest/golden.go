@@ -0,0 +1,198 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package senlogtest locks the log output of an application in tests: events
+// logged by a function are rendered without colors, with a fixed clock and
+// machine independent paths, and compared against golden files.
+package senlogtest
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/getsentry/sentry-go"
+)
+
+// Update rewrites the golden files with the current output: go test -senlog.update
+var Update = flag.Bool("senlog.update", false, "update senlog golden files")
+
+// Clock is the fixed time of all events rendered by Render and Golden
+var Clock = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	destinationKey = "senlogtest"
+	modulePath     = "github.com/ejazmughal/senlog/senlogtest"
+)
+
+var (
+	mu   sync.Mutex // one rendering at a time, the clock is global
+	ansi = regexp.MustCompile("\033\\[[0-9;]*m")
+)
+
+// Golden renders the events logged by fn in format and compares the output
+// with testdata/<name>.golden, the test fails on a difference
+func Golden(t testing.TB, name string, format senlog.Format, fn func()) {
+
+	t.Helper()
+
+	got, err := Render(format, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join("testdata", name+".golden")
+
+	if *Update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v, run the test with -senlog.update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("log output differs from %s\ngot:\n%s\nwant:\n%s", file, got, want)
+	}
+}
+
+// Render returns the events logged by fn as written by a console transport in
+// format, without colors. Timestamps are Clock, event ids are zero, stacktrace
+// paths are file names and sentry's os, device and runtime contexts are removed.
+func Render(format senlog.Format, fn func()) ([]byte, error) {
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	rec := new(recorder)
+	if err := senlog.AddDestination(destinationKey, sentry.ClientOptions{Transport: rec}); err != nil {
+		return nil, err
+	}
+	rec.start() // setup notices of the destination are not part of the output
+
+	senlog.SetClock(func() time.Time { return Clock })
+	defer senlog.SetClock(nil)
+
+	fn()
+
+	events := rec.stop()
+	senlog.RemoveDestination(destinationKey)
+
+	var buf bytes.Buffer
+	t := senlog.NewTransport(&buf, senlog.WithColors(nil), senlog.WithFormat(format), senlog.WithTimeFormat(log.Ltime|log.LUTC))
+	for _, ev := range events {
+		t.SendEvent(normalize(ev))
+	}
+
+	return ansi.ReplaceAll(buf.Bytes(), nil), nil
+}
+
+// machine and run independent copy of the event
+func normalize(ev *sentry.Event) *sentry.Event {
+
+	c := *ev
+	c.EventID = "00000000000000000000000000000000"
+	c.ServerName = ""
+	c.Timestamp = Clock
+
+	c.Contexts = make(map[string]interface{}, len(ev.Contexts))
+	for name, fields := range ev.Contexts {
+		switch name {
+		case "os", "device", "runtime":
+		default:
+			c.Contexts[name] = fields
+		}
+	}
+
+	c.Exception = make([]sentry.Exception, len(ev.Exception))
+	for i, ex := range ev.Exception {
+		ex.Stacktrace = normalizeStacktrace(ex.Stacktrace)
+		c.Exception[i] = ex
+	}
+	c.Threads = make([]sentry.Thread, len(ev.Threads))
+	for i, th := range ev.Threads {
+		th.Stacktrace = normalizeStacktrace(th.Stacktrace)
+		c.Threads[i] = th
+	}
+
+	return &c
+}
+
+func normalizeStacktrace(st *sentry.Stacktrace) *sentry.Stacktrace {
+
+	if st == nil {
+		return nil
+	}
+
+	// frames are oldest first, keep the ones called by fn
+	frames := st.Frames
+	for i := len(frames) - 1; i >= 0; i-- {
+		if frames[i].Module == modulePath {
+			frames = frames[i+1:]
+			break
+		}
+	}
+
+	c := *st
+	c.Frames = make([]sentry.Frame, len(frames))
+	for i, f := range frames {
+		f.AbsPath = filepath.Base(f.AbsPath)
+		f.Filename = filepath.Base(f.Filename)
+		c.Frames[i] = f
+	}
+	return &c
+}
+
+// transport keeping the events sent while recording
+type recorder struct {
+	mu        sync.Mutex
+	recording bool
+	events    []*sentry.Event
+}
+
+func (r *recorder) start() {
+	r.mu.Lock()
+	r.recording = true
+	r.mu.Unlock()
+}
+
+func (r *recorder) stop() []*sentry.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = false
+	return r.events
+}
+
+func (r *recorder) Configure(sentry.ClientOptions) {}
+
+func (r *recorder) SendEvent(ev *sentry.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.recording {
+		r.events = append(r.events, ev)
+	}
+}
+
+func (r *recorder) Flush(time.Duration) bool {
+	return true
+}
@@ -0,0 +1,104 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+// Package senredis routes go-redis client logs, failed dials, command errors
+// and slow commands through senlog.
+package senredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ejazmughal/senlog"
+	"github.com/redis/go-redis/v9"
+)
+
+// Logger receives go-redis internal logs (pool and connection problems) and
+// logs them as warnings, use it with redis.SetLogger(senredis.Logger{})
+type Logger struct{}
+
+func (Logger) Printf(ctx context.Context, format string, v ...interface{}) {
+	senlog.Cxt("redis").WRNCtx(ctx, fmt.Sprintf(format, v...))
+}
+
+// Hook implements redis.Hook, add it with client.AddHook(senredis.NewHook(...))
+type Hook struct {
+	SlowThreshold time.Duration // commands taking longer are logged as WARN, 0 disables
+	LogErrors     bool          // log failed commands (redis.Nil is never logged)
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+// NewHook returns a hook logging commands slower than slowThreshold and failed commands
+func NewHook(slowThreshold time.Duration) *Hook {
+	return &Hook{SlowThreshold: slowThreshold, LogErrors: true}
+}
+
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			senlog.Cxt("redis").Set("network", network).Set("addr", addr).ERRCtx(ctx, err, "Redis dial failed")
+		}
+		return conn, err
+	}
+}
+
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+
+	return func(ctx context.Context, cmd redis.Cmder) error {
+
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.log(ctx, []redis.Cmder{cmd}, time.Since(start), err)
+		return err
+	}
+}
+
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.log(ctx, cmds, time.Since(start), err)
+		return err
+	}
+}
+
+// command arguments are not logged, they may hold user data
+func (h *Hook) log(ctx context.Context, cmds []redis.Cmder, duration time.Duration, err error) {
+
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.FullName()
+	}
+
+	x := senlog.Cxt("redis").Set("duration", duration.String())
+	if len(cmds) == 1 {
+		x = x.Set("command", names[0])
+	} else {
+		x = x.Set("pipeline", names)
+	}
+
+	if err != nil && !errors.Is(err, redis.Nil) && h.LogErrors {
+		x.ERRCtx(ctx, err, "Redis command failed")
+		return
+	}
+
+	if h.SlowThreshold > 0 && duration > h.SlowThreshold {
+		x.Set("threshold", h.SlowThreshold.String()).WRNCtx(ctx, "Slow redis command")
+	}
+}
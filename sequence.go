@@ -0,0 +1,118 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+)
+
+// SetOrdering guarantees that the events of a request, or of a goroutine
+// outside of requests, arrive at each destination in the order they were
+// logged, also with send timeouts, parallel dispatch and shadows, which send
+// in the background. Events of different sequences are still sent
+// concurrently. Requests are identified by RequestIDMiddleware. Ordering takes
+// precedence over the priority lane of ERROR and FATAL events, see
+// SetPriorityLanes.
+func SetOrdering(on bool) {
+	updateSettings(func(s *settings) { s.ordering = on })
+}
+
+// sequence of an event logged with ctx, empty without ordering
+func sequenceKey(ctx context.Context) string {
+
+	if !loadSettings().ordering {
+		return ""
+	}
+	if id := RequestID(ctx); id != "" {
+		return "request " + id
+	}
+	return "goroutine " + goroutineID()
+}
+
+// id of the calling goroutine, from the first line of its stack:
+// "goroutine 18 [running]:"
+func goroutineID() string {
+
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// turn of an event in its sequence at a destination, the zero turn is unordered
+type turn struct {
+	key  string
+	prev chan struct{} // closed when the event before is sent, nil if there is none
+	done chan struct{} // closed when the event is sent
+}
+
+// next turn of sequence key at the destination
+func (d *destination) turn(key string) turn {
+
+	if key == "" {
+		return turn{}
+	}
+
+	t := turn{key: key, done: make(chan struct{})}
+
+	d.mu.Lock()
+	if d.sequences == nil {
+		d.sequences = make(map[string]chan struct{})
+	}
+	t.prev = d.sequences[key]
+	d.sequences[key] = t.done
+	d.mu.Unlock()
+
+	return t
+}
+
+// waits for the event before
+func (t turn) wait() {
+	if t.prev != nil {
+		<-t.prev
+	}
+}
+
+// lets the event after go
+func (d *destination) finish(t turn) {
+
+	if t.done == nil {
+		return
+	}
+
+	close(t.done)
+
+	d.mu.Lock()
+	if d.sequences[t.key] == t.done { // last event of the sequence
+		delete(d.sequences, t.key)
+	}
+	d.mu.Unlock()
+}
+
+// gives up the turn of a dropped event, the event after still waits for the
+// event before
+func (d *destination) skip(t turn) {
+
+	if t.prev == nil {
+		d.finish(t)
+		return
+	}
+
+	go func() {
+		t.wait()
+		d.finish(t)
+	}()
+}
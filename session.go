@@ -0,0 +1,217 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// release health session of the program run, sentry-go 0.13 has no session
+// support, so senlog sends session updates to Sentry destinations itself
+type session struct {
+	mu       sync.Mutex
+	id       string
+	started  time.Time
+	errors   int
+	status   string // ok, exited, crashed or abnormal
+	sentInit bool
+}
+
+var (
+	sessionMu      sync.Mutex
+	currentSession *session
+)
+
+// StartSession starts a release health session of the program run, reported
+// to all Sentry destinations with a Release set. Events of level ERROR and up
+// count as session errors, FTL ends it as crashed, EndSession or Shutdown as
+// exited. A running session is ended first, as abnormal since nothing ended it.
+func StartSession() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+	defer cancel()
+
+	endSession(ctx, "abnormal")
+
+	s := &session{id: uuid(), started: now(), status: "ok"}
+
+	sessionMu.Lock()
+	currentSession = s
+	sessionMu.Unlock()
+
+	s.send(ctx, false)
+}
+
+// EndSession ends the session of StartSession as exited, waiting at most
+// FlushTimeout for Sentry, see Shutdown to bound it with a context
+func EndSession() {
+
+	ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+	defer cancel()
+
+	endSession(ctx, "exited")
+}
+
+// ends the running session with status, ctx bounds sending the update
+func endSession(ctx context.Context, status string) {
+
+	sessionMu.Lock()
+	s := currentSession
+	currentSession = nil
+	sessionMu.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	s.send(ctx, true)
+}
+
+// counts an event of level ERROR or FATAL, the first error is reported right
+// away, so the session shows as errored even if the program is killed
+func sessionError() {
+
+	sessionMu.Lock()
+	s := currentSession
+	sessionMu.Unlock()
+
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.errors++
+	first := s.errors == 1
+	s.mu.Unlock()
+
+	if first {
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), FlushTimeout)
+			defer cancel()
+			s.send(ctx, false)
+		}()
+	}
+}
+
+// session update payload
+func (s *session) update(final bool, release string, env string) map[string]interface{} {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := now()
+	u := map[string]interface{}{
+		"sid":       s.id,
+		"init":      !s.sentInit,
+		"started":   s.started.UTC().Format(time.RFC3339Nano),
+		"timestamp": ts.UTC().Format(time.RFC3339Nano),
+		"status":    s.status,
+		"errors":    s.errors,
+		"attrs":     map[string]string{"release": release, "environment": env},
+	}
+	if final {
+		u["duration"] = ts.Sub(s.started).Seconds()
+	}
+	return u
+}
+
+// sends the session state to the Sentry destinations, synchronously as it runs
+// rarely and FTL exits right after
+func (s *session) send(ctx context.Context, final bool) {
+
+	for _, d := range destinations() {
+
+		options := d.hub.Client().Options()
+		if _, ok := options.Transport.(*SentryTransport); !ok || options.Dsn == "" {
+			continue
+		}
+		if options.Release == "" {
+			diagnose(fmt.Errorf("destination %s has no release, its sessions are not reported", d.key))
+			continue
+		}
+
+		if err := sendSession(ctx, options, s.update(final, options.Release, options.Environment)); err != nil {
+			diagnose(fmt.Errorf("could not send session to destination %s: %w", d.key, err))
+		}
+	}
+
+	s.mu.Lock()
+	s.sentInit = true
+	s.mu.Unlock()
+}
+
+// posts an envelope with a session item to the DSN of options
+func sendSession(ctx context.Context, options sentry.ClientOptions, update map[string]interface{}) error {
+
+	dsn, err := sentry.NewDsn(options.Dsn)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `{"sent_at":%q}`+"\n", time.Now().UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&body, `{"type":"session","length":%d}`+"\n", len(payload))
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dsn.EnvelopeAPIURL().String(), &body)
+	if err != nil {
+		return err
+	}
+	for k, v := range dsn.RequestHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	client := options.HTTPClient
+	if client == nil {
+		client = &http.Client{Transport: options.HTTPTransport, Timeout: FlushTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry responded %s", resp.Status)
+	}
+	return nil
+}
+
+// random uuid4 in its canonical form, as sessions want it
+func uuid() string {
+	id := string(newEventID())
+	if len(id) != 32 {
+		return id
+	}
+	return id[:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:]
+}
@@ -0,0 +1,100 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Sentry server recording the session statuses posted, hung servers block
+// until the test ends
+func sessionServer(t *testing.T, hung bool) (dsn string, statuses func() []string) {
+
+	var mu sync.Mutex
+	var got []string
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hung {
+			<-release
+			return
+		}
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var item struct{ Status string }
+			if json.Unmarshal(scanner.Bytes(), &item) == nil && item.Status != "" {
+				mu.Lock()
+				got = append(got, item.Status)
+				mu.Unlock()
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+
+	return "http://public@" + strings.TrimPrefix(srv.URL, "http://") + "/1", func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), got...)
+	}
+}
+
+func addSessionDestination(t *testing.T, dsn string) {
+	addTestDestination(t, "sessions", sentry.ClientOptions{Dsn: dsn, Release: "test@1", Transport: NewSentryTransport(FATAL + 1)})
+}
+
+func TestRestartedSessionEndsAbnormal(t *testing.T) {
+
+	quiet(t)
+	dsn, statuses := sessionServer(t, false)
+	addSessionDestination(t, dsn)
+
+	StartSession()
+	StartSession() // the first one was never ended
+	EndSession()
+
+	want := []string{"ok", "abnormal", "ok", "exited"}
+	if got := statuses(); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("session statuses %q, want %q", got, want)
+	}
+}
+
+func TestEndSessionHonorsContext(t *testing.T) {
+
+	quiet(t)
+	dsn, _ := sessionServer(t, true)
+
+	sessionMu.Lock()
+	currentSession = &session{id: uuid(), started: now(), status: "ok", sentInit: true}
+	sessionMu.Unlock()
+	addSessionDestination(t, dsn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	endSession(ctx, "exited")
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("ending the session took %s past its context", d)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+)
+
+// SetupVerbosity controls the notices senlog logs while destinations are set up
+type SetupVerbosity int32
+
+const (
+	SetupVerbose SetupVerbosity = iota // all notices, the default
+	SetupQuiet                         // warnings only, e.g. about an empty DSN
+	SetupSilent                        // no notices
+)
+
+// SetSetupVerbosity sets which setup notices are logged, libraries embedding
+// senlog can use SetupSilent to initialize without output
+func SetSetupVerbosity(v SetupVerbosity) {
+	updateSettings(func(s *settings) { s.setupVerbosity = v })
+}
+
+// log a setup notice to this destination only
+func (d *destination) notify(level Level, x *Context, msg string) {
+
+	switch loadSettings().setupVerbosity {
+	case SetupSilent:
+		return
+	case SetupQuiet:
+		if level < WARN {
+			return
+		}
+	}
+
+	d.send(context.Background(), newRecord(level, nil, x, msg))
+}
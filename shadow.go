@@ -0,0 +1,81 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SetShadow makes a destination the shadow of a primary destination, to
+// evaluate a new backend side by side with the current one. The shadow
+// receives the events routed to the primary, in the background, its
+// transport level still applies. Its drops and failures are counted in its
+// Stats only, they never count as dropped by Shutdown or make events Enabled.
+// An empty primaryKey makes the shadow a normal destination again.
+func SetShadow(destinationKey string, primaryKey string) error {
+
+	d, exists := lookup(destinationKey)
+	if !exists {
+		return errors.New("Destination doesn't exist: " + destinationKey)
+	}
+
+	if primaryKey != "" {
+		p, exists := lookup(primaryKey)
+		switch {
+		case !exists:
+			return errors.New("Destination doesn't exist: " + primaryKey)
+		case p == d:
+			return errors.New("shadow: destination " + destinationKey + " can't shadow itself")
+		case p.primary() != "":
+			return errors.New("shadow: primary " + primaryKey + " is a shadow itself")
+		}
+	}
+
+	d.mu.Lock()
+	d.shadowOf = primaryKey
+	d.mu.Unlock()
+	return nil
+}
+
+// key of the destination d shadows, empty if d is no shadow
+func (d *destination) primary() string {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.shadowOf
+}
+
+// sends r, as routed to the primary, to the primary's shadows without
+// waiting for them
+func sendShadows(primaryKey string, r *Record, sequence string) {
+
+	for _, d := range destinations() {
+
+		if d.primary() != primaryKey || !d.accepts(r) || d.sampledOut(r) {
+			continue
+		}
+
+		t := d.turn(sequence)
+
+		pending.Add(1)
+		go func(d *destination) {
+			defer pending.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					diagnose(fmt.Errorf("shadow destination %s panicked: %v", d.key, p))
+				}
+			}()
+			d.sendTurn(context.Background(), r, t)
+		}(d)
+	}
+}
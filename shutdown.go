@@ -0,0 +1,68 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+var shutdown int32 // set to 1 by Shutdown, capture drops new events afterwards
+
+// Shutdown stops accepting new events, waits for background sends, flushes and
+// closes all destination transports. It returns the number of events dropped by
+// all destinations during the program run, ctx bounds the whole shutdown:
+//
+//	defer senlog.Shutdown(context.Background())
+func Shutdown(ctx context.Context) (dropped uint64, err error) {
+
+	atomic.StoreInt32(&shutdown, 1)
+	endSession(ctx, "exited")
+
+	// drain sends still running in the background
+	drained := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	timeout := FlushTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	if timeout > 0 {
+		flush(timeout)
+	}
+
+	for _, d := range destinations() {
+
+		if c, ok := d.hub.Client().Transport.(io.Closer); ok {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+
+		if d.primary() == "" { // drops of shadows don't count
+			dropped += atomic.LoadUint64(&d.dropped) + rateLimited(d)
+		}
+	}
+
+	return dropped, err
+}
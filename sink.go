@@ -0,0 +1,132 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"io"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Sink is a destination backend receiving Records, a simpler alternative to
+// implementing sentry.Transport. Send may be called concurrently.
+type Sink interface {
+	Send(r Record)
+	Flush(timeout time.Duration) bool
+	Close() error
+}
+
+// AddSink adds a destination sending events of minLogLevel and up to s. The
+// sink gets the records as logged, they are not converted to sentry events:
+// no sentry scopes, e.g. of sentry.ConfigureScope, no os, device and runtime
+// contexts, no BeforeSend and no event processors. Destinations added with a
+// SinkTransport and without BeforeSend get their records the same way.
+func AddSink(key string, s Sink, minLogLevel Level) error {
+	return AddDestination(key, sentry.ClientOptions{Transport: NewSinkTransport(s, minLogLevel)})
+}
+
+// SinkTransport is the sentry.Transport of a Sink, for AddDestination with
+// further client options
+type SinkTransport struct {
+	Logger
+	sink Sink
+}
+
+func NewSinkTransport(s Sink, minLogLevel Level) *SinkTransport {
+
+	t := &SinkTransport{sink: s}
+	t.SetLogLevel(minLogLevel)
+	return t
+}
+
+func (t *SinkTransport) Configure(options sentry.ClientOptions) {}
+
+func (t *SinkTransport) SendEvent(ev *sentry.Event) {
+	t.Call(func(ev *sentry.Event) { t.sink.Send(recordOf(ev)) }, ev)
+}
+
+// sends r of a destination bypassing its sentry client, see directSink
+func (t *SinkTransport) send(r Record) {
+
+	if r.Level >= t.MinLogLevel() || r.levelExempt() {
+		t.sink.Send(r)
+	}
+}
+
+func (t *SinkTransport) Flush(timeout time.Duration) bool {
+	return t.sink.Flush(timeout)
+}
+
+func (t *SinkTransport) Close() error {
+	return t.sink.Close()
+}
+
+// sink transport of a destination bypassing its sentry client, see AddSink
+func directSink(options sentry.ClientOptions) *SinkTransport {
+
+	if t, ok := options.Transport.(*SinkTransport); ok && options.BeforeSend == nil {
+		return t
+	}
+	return nil
+}
+
+// hands r to the destination: a direct sink gets the record, other
+// destinations its sentry event through their client
+func (d *destination) capture(r *Record) {
+
+	r = d.own(r)
+
+	if d.sink != nil {
+		d.sink.send(*r)
+		return
+	}
+	if ev := d.event(r); ev != nil {
+		d.hub.CaptureEvent(ev)
+	}
+}
+
+// sentry event of the destination's record r with the sentry scope of its log
+// call applied, nil if an event processor of the scope dropped it
+func (d *destination) event(r *Record) *sentry.Event {
+
+	ev := applySentryScope(r.scope, r.event())
+	if ev == nil {
+		d.filter(r, DroppedByScope)
+	}
+	return ev
+}
+
+// TransportSink adapts a sentry.Transport, e.g. one of senlog's transports, to
+// a Sink, to combine it with sinks. The transport must be configured.
+func TransportSink(t sentry.Transport) Sink {
+	return transportSink{t}
+}
+
+type transportSink struct {
+	transport sentry.Transport
+}
+
+func (s transportSink) Send(r Record) {
+	s.transport.SendEvent(r.event())
+}
+
+func (s transportSink) Flush(timeout time.Duration) bool {
+	return s.transport.Flush(timeout)
+}
+
+func (s transportSink) Close() error {
+
+	if c, ok := s.transport.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
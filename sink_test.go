@@ -0,0 +1,138 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+type recordingSink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *recordingSink) Send(r Record) {
+	s.mu.Lock()
+	s.records = append(s.records, r)
+	s.mu.Unlock()
+}
+
+func (s *recordingSink) Flush(time.Duration) bool { return true }
+func (s *recordingSink) Close() error             { return nil }
+
+func (s *recordingSink) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records...)
+}
+
+func TestSentryScopeAppliesToClientDestinationsOnly(t *testing.T) {
+
+	quiet(t)
+	sink := new(recordingSink)
+	if err := AddSink("sink", sink, DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RemoveDestination("sink") })
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	hub := sentry.CurrentHub().Clone()
+	hub.Scope().SetTag("region", "eu")
+	hub.Scope().AddEventProcessor(func(ev *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+		if ev.Message == "dropped" {
+			return nil
+		}
+		return ev
+	})
+	ctx := sentry.SetHubOnContext(context.Background(), hub)
+
+	Cxt("order").INFCtx(ctx, "kept")
+	Cxt("order").INFCtx(ctx, "dropped")
+
+	var events []string
+	for _, ev := range rec.Events() {
+		if ev.Message != "kept" && ev.Message != "dropped" { // setup notice
+			continue
+		}
+		events = append(events, ev.Message)
+		if ev.Tags["region"] != "eu" {
+			t.Errorf("scope tag missing: %v", ev.Tags)
+		}
+	}
+	if len(events) != 1 || events[0] != "kept" {
+		t.Errorf("client destination got %q, want the event not dropped by the scope", events)
+	}
+
+	var records []string
+	for _, r := range sink.Records() {
+		if r.Msg != "kept" && r.Msg != "dropped" {
+			continue
+		}
+		records = append(records, r.Msg)
+		if _, ok := r.Tags["region"]; ok {
+			t.Errorf("sink got the scope tag: %v", r.Tags)
+		}
+	}
+	if len(records) != 2 {
+		t.Errorf("sink got %q, want both records", records)
+	}
+}
+
+func TestSinkGetsTheRecordAsLogged(t *testing.T) {
+
+	quiet(t)
+	sink := new(recordingSink)
+	if err := AddSink("sink", sink, DEBUG); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { RemoveDestination("sink") })
+	rec := newRecordingTransport(DEBUG)
+	addTestDestination(t, "rec", sentry.ClientOptions{Transport: rec})
+
+	Set("user", "ann").Cxt("order").Set("id", 7).ERR(errors.New("failed"), "Order failed")
+
+	var got *Record
+	for _, r := range sink.Records() {
+		if r.Msg == "Order failed" {
+			r := r
+			got = &r
+		}
+	}
+	var ev *sentry.Event
+	for _, e := range rec.Events() {
+		if e.Message == "Order failed" {
+			ev = e
+		}
+	}
+	if got == nil || ev == nil {
+		t.Fatal("not sent to both destinations")
+	}
+
+	if got.ID != string(ev.EventID) || got.Level != ERROR {
+		t.Errorf("record %s of level %v, want %s of ERROR", got.ID, got.Level, ev.EventID)
+	}
+	if got.Fields["user"] != "ann" || got.Contexts["order"]["id"] != 7 {
+		t.Errorf("fields %v and contexts %v, want the fields logged", got.Fields, got.Contexts)
+	}
+	if len(got.Errors) != 1 || got.Errors[0].Value != "failed" || got.Errors[0].Type != "*errors.errorString" {
+		t.Errorf("errors %+v, want the error logged", got.Errors)
+	}
+	if len(ev.Exception) != 1 || ev.Exception[0].Value != "failed" {
+		t.Errorf("event exceptions %+v, want the error logged", ev.Exception)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SLI turns log events into a service level indicator: of the events Match
+// selects, those Bad selects count as bad, the others as good. Events are
+// counted once when logged, whatever destinations receive them.
+//
+//	senlog.AddSLI(senlog.SLI{
+//		Name:  "api-availability",
+//		Match: senlog.HasField("http.client.status"),
+//		Bad:   senlog.FieldAtLeast("http.client.status", 500),
+//	})
+type SLI struct {
+	Name  string
+	Match func(ev *sentry.Event) bool // nil matches all events
+	Bad   func(ev *sentry.Event) bool
+}
+
+// SLICount are the counters of an SLI since it was added
+type SLICount struct {
+	Good uint64
+	Bad  uint64
+}
+
+type sli struct {
+	SLI
+	good uint64
+	bad  uint64
+}
+
+// SLI registry, the slice stored is never modified, like the destinations
+var (
+	slisMu sync.Mutex
+	slis   atomic.Value // []*sli
+)
+
+func loadSLIs() []*sli {
+	s, _ := slis.Load().([]*sli)
+	return s
+}
+
+// AddSLI starts counting the events of s
+func AddSLI(s SLI) error {
+
+	if s.Name == "" || s.Bad == nil {
+		return errors.New("SLI needs a name and a Bad func")
+	}
+
+	slisMu.Lock()
+	defer slisMu.Unlock()
+
+	current := loadSLIs()
+	for _, c := range current {
+		if c.Name == s.Name {
+			return errors.New("SLI already exists: " + s.Name)
+		}
+	}
+	updated := make([]*sli, len(current), len(current)+1)
+	copy(updated, current)
+	slis.Store(append(updated, &sli{SLI: s}))
+	return nil
+}
+
+// RemoveSLI stops counting the events of an SLI and drops its counters
+func RemoveSLI(name string) {
+
+	slisMu.Lock()
+	defer slisMu.Unlock()
+
+	current := loadSLIs()
+	updated := make([]*sli, 0, len(current))
+	for _, c := range current {
+		if c.Name != name {
+			updated = append(updated, c)
+		}
+	}
+	slis.Store(updated)
+}
+
+// SLICounts returns the good and bad counters of all SLIs by name, e.g. for a
+// metrics exporter computing error rates and budgets from them
+func SLICounts() map[string]SLICount {
+
+	counts := make(map[string]SLICount)
+	for _, s := range loadSLIs() {
+		counts[s.Name] = SLICount{Good: atomic.LoadUint64(&s.good), Bad: atomic.LoadUint64(&s.bad)}
+	}
+	return counts
+}
+
+// counts r for every SLI matching it, the predicates get its sentry event
+func countSLIs(r *Record) {
+
+	all := loadSLIs()
+	if len(all) == 0 {
+		return
+	}
+
+	ev := r.event()
+	for _, s := range all {
+		if s.Match != nil && !s.Match(ev) {
+			continue
+		}
+		if s.Bad(ev) {
+			atomic.AddUint64(&s.bad, 1)
+		} else {
+			atomic.AddUint64(&s.good, 1)
+		}
+	}
+}
+
+// Field returns a field of ev by path: "key" for fields of the default
+// context, "context.key" for fields of a named context
+func Field(ev *sentry.Event, path string) (interface{}, bool) {
+
+	group, key := "Default Context", path
+	if i := strings.LastIndex(path, "."); i > 0 {
+		if _, ok := ev.Contexts[path[:i]]; ok {
+			group, key = path[:i], path[i+1:]
+		}
+	}
+
+	fields, ok := ev.Contexts[group].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	v, ok := fields[key]
+	return v, ok
+}
+
+// HasField matches events with the field at path, see Field
+func HasField(path string) func(ev *sentry.Event) bool {
+	return func(ev *sentry.Event) bool {
+		_, ok := Field(ev, path)
+		return ok
+	}
+}
+
+// FieldAtLeast matches events with a number field at path of at least min
+func FieldAtLeast(path string, min float64) func(ev *sentry.Event) bool {
+	return func(ev *sentry.Event) bool {
+		v, _ := Field(ev, path)
+		n, ok := number(v)
+		return ok && n >= min
+	}
+}
+
+// LevelAtLeast matches events of level and up
+func LevelAtLeast(level Level) func(ev *sentry.Event) bool {
+	return func(ev *sentry.Event) bool {
+		return senlogLevels[ev.Level] >= level
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,117 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SetAttachStacktrace attaches the stacktrace of the log call to ERR and FTL
+// events logged without an error, as the current thread of the event, so
+// Sentry can still group them and show the call site
+func SetAttachStacktrace(on bool) {
+	updateSettings(func(s *settings) { s.attachStacktrace = on })
+}
+
+func attachStacktrace() bool {
+	return loadSettings().attachStacktrace
+}
+
+// whether frames of module are senlog's own, compat wraps the API
+func internalModule(module string) bool {
+	return module == "github.com/ejazmughal/senlog" || module == "github.com/ejazmughal/senlog/compat"
+}
+
+// whether a function is senlog's own, see internalModule
+func internalFunction(function string) bool {
+	return strings.HasPrefix(function, "github.com/ejazmughal/senlog.") ||
+		strings.HasPrefix(function, "github.com/ejazmughal/senlog/compat.")
+}
+
+// stacktrace of the log call, senlog frames dropped
+func stacktrace() *sentry.Stacktrace {
+
+	st := sentry.NewStacktrace()
+
+	// drop senlog module frames
+	if st != nil {
+		threshold := len(st.Frames) - 1
+		for ; threshold > 0 && internalModule(st.Frames[threshold].Module); threshold-- {
+		}
+		st.Frames = st.Frames[:threshold+1]
+	}
+
+	return st
+}
+
+// StackTracer can be implemented by custom error types to report where the
+// error originated, as returned by runtime.Callers
+type StackTracer interface {
+	Callers() []uintptr
+}
+
+// stacktrace of the innermost error in the chain carrying its own stack
+// (StackTracer, pkg/errors, go-errors, pingcap/errors), nil if there is none
+func errorStacktrace(e error) *sentry.Stacktrace {
+
+	var origin *sentry.Stacktrace
+
+	for ; e != nil; e = errors.Unwrap(e) {
+
+		if st, ok := e.(StackTracer); ok {
+			if s := callersStacktrace(st.Callers()); s != nil {
+				origin = s
+			}
+			continue
+		}
+
+		if s := sentry.ExtractStacktrace(e); s != nil {
+			origin = s
+		}
+	}
+
+	return origin
+}
+
+func callersStacktrace(pcs []uintptr) *sentry.Stacktrace {
+
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	var frames []sentry.Frame
+	callers := runtime.CallersFrames(pcs)
+	for {
+		f, more := callers.Next()
+		frame := sentry.NewFrame(f)
+		if frame.Module != "runtime" && frame.Module != "testing" { // same as sentry's own filter
+			frames = append(frames, frame)
+		}
+		if !more {
+			break
+		}
+	}
+
+	if len(frames) == 0 {
+		return nil
+	}
+
+	// sentry expects the oldest frame first
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+
+	return &sentry.Stacktrace{Frames: frames}
+}
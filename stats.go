@@ -0,0 +1,65 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import "sync/atomic"
+
+// DestinationStats are the event counters of a destination since it was added
+// or the last ResetStats
+type DestinationStats struct {
+	Sent        uint64 // handed to the transport
+	Filtered    uint64 // below the transport's log level or sampled out
+	Dropped     uint64 // given up: caller context done, send timeout, circuit breaker open
+	Failed      uint64 // sends that timed out or failed in the transport, included in Dropped
+	RateLimited uint64 // dropped by Sentry's rate limits, included in Dropped, see SentryTransport.RateLimited
+	Shadow      bool   // shadow destination, see SetShadow
+}
+
+// Stats returns the counters of all destinations by key
+func Stats() map[string]DestinationStats {
+
+	stats := make(map[string]DestinationStats)
+	for _, d := range destinations() {
+		limited := rateLimited(d)
+		stats[d.key] = DestinationStats{
+			Sent:        atomic.LoadUint64(&d.sent),
+			Filtered:    atomic.LoadUint64(&d.filtered),
+			Dropped:     atomic.LoadUint64(&d.dropped) + limited,
+			Failed:      atomic.LoadUint64(&d.fails),
+			RateLimited: limited,
+			Shadow:      d.primary() != "",
+		}
+	}
+	return stats
+}
+
+// ResetStats sets the counters of all destinations to zero
+func ResetStats() {
+
+	for _, d := range destinations() {
+		atomic.StoreUint64(&d.sent, 0)
+		atomic.StoreUint64(&d.filtered, 0)
+		atomic.StoreUint64(&d.dropped, 0)
+		atomic.StoreUint64(&d.fails, 0)
+		if c, ok := d.hub.Client().Transport.(rateLimitCounter); ok {
+			c.resetRateLimited()
+		}
+	}
+}
+
+// events the destination's transport dropped by rate limits
+func rateLimited(d *destination) uint64 {
+
+	if c, ok := d.hub.Client().Transport.(rateLimitCounter); ok {
+		return c.RateLimited()
+	}
+	return 0
+}
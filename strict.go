@@ -0,0 +1,76 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// message of ERR/FTL events logged with a nil error and no message
+const DefaultNilErrorMessage = "error logged without error value"
+
+// SetStrictMode turns on checks for API misuse meant for development, e.g. a
+// warning with the call site is logged for ERR and FTL calls with a nil error
+func SetStrictMode(on bool) {
+	updateSettings(func(s *settings) { s.strictMode = on })
+}
+
+func strictMode() bool {
+	return loadSettings().strictMode
+}
+
+// SetNilErrorMessage sets the message used for ERR/FTL calls with a nil error
+// and an empty message. ERR(nil, ...) is logged as an error event without exception.
+func SetNilErrorMessage(msg string) {
+	updateSettings(func(s *settings) { s.nilErrorMessage = msg })
+}
+
+func nilErrorMessage(msg string) string {
+
+	if msg != "" {
+		return msg
+	}
+	if m := loadSettings().nilErrorMessage; m != "" {
+		return m
+	}
+	return DefaultNilErrorMessage
+}
+
+// true for nil and for interfaces holding a nil pointer, e.g. a nil *MyError
+func isNil(e error) bool {
+
+	if e == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(e)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// in strict mode, warn about an ERR/FTL call without error
+func checkNilError(e error) {
+
+	if !strictMode() || !isNil(e) {
+		return
+	}
+
+	x := Set("error", fmt.Sprintf("%#v", e))
+	if st := stacktrace(); st != nil && len(st.Frames) > 0 {
+		f := st.Frames[len(st.Frames)-1]
+		x = x.Set("caller", fmt.Sprintf("%s:%d", f.AbsPath, f.Lineno))
+	}
+	x.WRN("ERR/FTL called with nil error")
+}
@@ -0,0 +1,151 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Color is a terminal color of a Style
+type Color int
+
+const (
+	Black Color = iota
+	Red
+	Green
+	Yellow
+	Blue
+	Magenta
+	Cyan
+	White
+	BrightBlack
+	BrightRed
+	BrightGreen
+	BrightYellow
+	BrightBlue
+	BrightMagenta
+	BrightCyan
+	BrightWhite
+)
+
+// Style builds a terminal escape sequence, instead of writing raw escape strings:
+//
+//	senlog.NewStyle().Bold().Fg(senlog.Red).String() // "\033[1;31m"
+type Style struct {
+	codes []int
+}
+
+func NewStyle() Style {
+	return Style{}
+}
+
+func (s Style) with(code int) Style {
+	codes := make([]int, len(s.codes), len(s.codes)+1)
+	copy(codes, s.codes)
+	return Style{codes: append(codes, code)}
+}
+
+func (s Style) Bold() Style      { return s.with(1) }
+func (s Style) Dim() Style       { return s.with(2) }
+func (s Style) Italic() Style    { return s.with(3) }
+func (s Style) Underline() Style { return s.with(4) }
+
+// Fg sets the text color
+func (s Style) Fg(c Color) Style {
+	if c >= BrightBlack {
+		return s.with(90 + int(c-BrightBlack))
+	}
+	return s.with(30 + int(c))
+}
+
+// Bg sets the background color
+func (s Style) Bg(c Color) Style {
+	if c >= BrightBlack {
+		return s.with(100 + int(c-BrightBlack))
+	}
+	return s.with(40 + int(c))
+}
+
+// String returns the escape sequence, empty for a style without attributes
+func (s Style) String() string {
+
+	if len(s.codes) == 0 {
+		return ""
+	}
+
+	codes := make([]string, len(s.codes))
+	for i, c := range s.codes {
+		codes[i] = strconv.Itoa(c)
+	}
+	return "\033[" + strings.Join(codes, ";") + "m"
+}
+
+// SetLineStyle styles the whole text of events of a level, e.g. a red
+// background for ERROR and FATAL. The style is reset at the end of every line.
+// Like SetMessageStyle it is safe while the colors are used, LINE_COLORS stays
+// unchanged.
+//
+//	colors.SetLineStyle(senlog.ERROR, senlog.NewStyle().Bg(senlog.Red).Fg(senlog.BrightWhite))
+func (c *Colors) SetLineStyle(level Level, s Style) *Colors {
+	setStyle(&c.lineStyles, c.LINE_COLORS, level, s)
+	return c
+}
+
+func (c *Colors) lineStyle(level Level) string {
+	return loadStyle(&c.lineStyles, c.LINE_COLORS, level)
+}
+
+// SetMessageStyle styles the message text of events of a level, e.g. bold
+// red FATAL messages. It is safe while the colors are used for logging, the
+// styles are copied on write, MESSAGE_COLORS stays unchanged.
+//
+//	colors.SetMessageStyle(senlog.FATAL, senlog.NewStyle().Bold().Fg(senlog.Red))
+func (c *Colors) SetMessageStyle(level Level, s Style) *Colors {
+	setStyle(&c.messageStyles, c.MESSAGE_COLORS, level, s)
+	return c
+}
+
+func (c *Colors) messageStyle(level Level) string {
+	return loadStyle(&c.messageStyles, c.MESSAGE_COLORS, level)
+}
+
+// serializes the copy-on-write of styles
+var stylesMu sync.Mutex
+
+// stores a copy of the styles in v, or initially static, with the style of level
+func setStyle(v *atomic.Value, static map[Level]string, level Level, s Style) {
+
+	stylesMu.Lock()
+	defer stylesMu.Unlock()
+
+	current, ok := v.Load().(map[Level]string)
+	if !ok {
+		current = static
+	}
+	styles := make(map[Level]string, len(current)+1)
+	for l, style := range current {
+		styles[l] = style
+	}
+	styles[level] = s.String()
+	v.Store(styles)
+}
+
+// style of level stored in v, static if none was set
+func loadStyle(v *atomic.Value, static map[Level]string, level Level) string {
+
+	if styles, ok := v.Load().(map[Level]string); ok {
+		return styles[level]
+	}
+	return static[level]
+}
@@ -0,0 +1,65 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// formats events with c while set changes its styles, for the race detector
+func styleWhileLogging(c *Colors, set func(level Level)) {
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			set(Level(i%5 + 1))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			FormatEvent(&sentry.Event{Level: sentry.LevelError, Message: "styled"}, c)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSetMessageStyleWhileLogging(t *testing.T) {
+
+	c := &Colors{RESET_COLOR: "\033[0m", MESSAGE_COLORS: map[Level]string{INFO: "info"}}
+	styleWhileLogging(c, func(l Level) { c.SetMessageStyle(l, NewStyle().Bold()) })
+
+	if text := FormatEvent(&sentry.Event{Level: sentry.LevelError, Message: "styled"}, c); !strings.Contains(text, "\033[1mstyled") {
+		t.Errorf("text = %q, want the bold message", text)
+	}
+	if c.MESSAGE_COLORS[ERROR] != "" || c.messageStyle(INFO) != "\033[1m" {
+		t.Errorf("MESSAGE_COLORS = %q, want it unchanged and overridden", c.MESSAGE_COLORS)
+	}
+}
+
+func TestSetLineStyleWhileLogging(t *testing.T) {
+
+	c := &Colors{RESET_COLOR: "\033[0m"}
+	styleWhileLogging(c, func(l Level) { c.SetLineStyle(l, NewStyle().Bg(Red)) })
+
+	if text := FormatEvent(&sentry.Event{Level: sentry.LevelError, Message: "styled"}, c); !strings.HasPrefix(text, "\033[41m") {
+		t.Errorf("text = %q, want the red line", text)
+	}
+	if c.LINE_COLORS != nil {
+		t.Errorf("LINE_COLORS = %q, want it unchanged", c.LINE_COLORS)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"bytes"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// sd-daemon line prefixes of sentry levels, syslog priorities
+var syslogPriorities = map[sentry.Level]string{
+	sentry.LevelDebug:   "<7>", // debug
+	sentry.LevelInfo:    "<6>", // info
+	sentry.LevelWarning: "<4>", // warning
+	sentry.LevelError:   "<3>", // err
+	sentry.LevelFatal:   "<2>", // crit
+}
+
+// b with prefix before each of its lines
+func withPriority(b []byte, prefix string) []byte {
+
+	if prefix == "" {
+		return b
+	}
+
+	lines := bytes.Split(b, []byte("\n"))
+	out := make([]byte, 0, len(b)+len(lines)*len(prefix))
+	for i, line := range lines {
+		if i > 0 {
+			out = append(out, '\n')
+		}
+		out = append(out, prefix...)
+		out = append(out, line...)
+	}
+	return out
+}
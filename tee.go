@@ -0,0 +1,144 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// TeeOutput is a writer of a TeeTransport with its own format
+type TeeOutput struct {
+	Writer   io.Writer
+	Format   Format  // TextFormat or a JSON format written one document per line
+	Colors   *Colors // colors of TextFormat lines, nil for plain text
+	MinLevel Level   // minimum severity level written, DEBUG if not set
+}
+
+// TeeTransport writes every event to several writers, e.g. colored text for
+// humans on stdout and NDJSON for machines on a pipe. Each distinct output
+// format is rendered once per event and shared by the writers using it.
+type TeeTransport struct {
+	Logger // destination wide minimum level, DEBUG by default
+
+	outputs []TeeOutput
+	mu      sync.Mutex // serializes writes, keeps the outputs in the same order
+}
+
+// NewTeeTransport returns a transport writing to all outputs
+func NewTeeTransport(outputs ...TeeOutput) *TeeTransport {
+
+	t := new(TeeTransport)
+	t.SetLogLevel(DEBUG)
+	for _, o := range outputs {
+		if o.MinLevel == 0 {
+			o.MinLevel = DEBUG
+		}
+		t.outputs = append(t.outputs, o)
+	}
+	return t
+}
+
+// MinLogLevel is the lowest level any output writes, but not below the
+// destination wide level
+func (t *TeeTransport) MinLogLevel() Level {
+
+	level := Level(FATAL + 1)
+	for _, o := range t.outputs {
+		if o.MinLevel < level {
+			level = o.MinLevel
+		}
+	}
+	if min := t.Logger.MinLogLevel(); level < min {
+		level = min
+	}
+	return level
+}
+
+func (t *TeeTransport) Configure(options sentry.ClientOptions) {
+}
+
+// output rendering shared by outputs of the same format and colors
+type teeRender struct {
+	format Format
+	colors *Colors
+}
+
+func (t *TeeTransport) SendEvent(ev *sentry.Event) {
+
+	if !t.logs(ev) {
+		return
+	}
+
+	level := senlogLevels[ev.Level]
+	rendered := make(map[teeRender][]byte, len(t.outputs))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, o := range t.outputs {
+
+		if level < o.MinLevel && !levelExempt(ev) {
+			continue
+		}
+
+		key := teeRender{format: o.Format, colors: o.Colors}
+		b, ok := rendered[key]
+		if !ok {
+			b = renderLine(ev, o.Format, o.Colors)
+			rendered[key] = b
+		}
+		if b == nil {
+			continue
+		}
+
+		if _, err := o.Writer.Write(b); err != nil {
+			diagnose(err)
+		}
+	}
+}
+
+// one output line of the event, nil if it can not be rendered
+func renderLine(ev *sentry.Event, f Format, c *Colors) []byte {
+
+	if f != TextFormat {
+		b, err := formatDocument(f, ev)
+		if err != nil {
+			diagnose(fmt.Errorf("could not encode event %s: %w", ev.EventID, err))
+			return nil
+		}
+		return append(b, '\n')
+	}
+
+	level := senlogLevels[ev.Level]
+	if level < DEBUG || level > FATAL {
+		return nil
+	}
+
+	tags := levelTags
+	if c != nil {
+		tags = coloredLevelTags
+	} else {
+		c = &Colors{}
+	}
+
+	return []byte(fmt.Sprintf("%s%s%s %s%s%s\n", c.TIME_COLOR, ev.Timestamp.Format("15:04:05"), c.RESET_COLOR,
+		tags[level-1], strings.TrimSuffix(FormatEvent(ev, c), "\n"), c.RESET_COLOR))
+}
+
+func (t *TeeTransport) Flush(_ time.Duration) bool {
+	return true
+}
@@ -0,0 +1,231 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// TenantTransport sends events to the Sentry project of their tenant, named by
+// the tag or field TenantKey of the event. DSN resolves the DSN of a tenant.
+// At most MaxClients tenant transports are kept, the least recently used one
+// is evicted, as are transports idle for IdleTimeout. All tenants share one
+// HTTP connection pool. Events without tenant go to the DSN of the destination,
+// if any, as do events of tenants DSN failed for, until RetryAfter passed.
+// Tenant transports send synchronously like NewSentryTransport.
+//
+//	senlog.AddDestination("tenants", sentry.ClientOptions{
+//		Transport: senlog.NewTenantTransport(lookupDSN, 100, senlog.ERROR),
+//	})
+//	senlog.Set("tenant", id).ERR(err, "Import failed")
+type TenantTransport struct {
+	Logger
+
+	TenantKey   string                              // "tenant" by default
+	MaxClients  int                                 // live tenant transports
+	IdleTimeout time.Duration                       // evicts tenant transports unused that long on the next send, 0 never
+	DSN         func(tenant string) (string, error) // DSN of a tenant, called without holding locks
+	RetryAfter  time.Duration                       // a tenant without DSN is looked up again after that long, a minute by default
+
+	mu       sync.Mutex
+	options  sentry.ClientOptions // of the destination, the DSN is replaced per tenant
+	fallback *SentryTransport     // for events without tenant, nil without destination DSN
+	lru      *list.List           // of *tenantClient, most recently used first
+	clients  map[string]*list.Element
+	failed   map[string]time.Time     // tenants without DSN, until their next lookup
+	lookups  map[string]chan struct{} // DSN lookups running, closed when done
+	pool     http.RoundTripper
+}
+
+type tenantClient struct {
+	tenant    string
+	transport *SentryTransport
+	lastUsed  time.Time
+}
+
+func NewTenantTransport(dsn func(tenant string) (string, error), maxClients int, minLogLevel Level) *TenantTransport {
+
+	t := &TenantTransport{
+		TenantKey:  "tenant",
+		MaxClients: maxClients,
+		DSN:        dsn,
+		RetryAfter: time.Minute,
+		lru:        list.New(),
+		clients:    make(map[string]*list.Element),
+		failed:     make(map[string]time.Time),
+		lookups:    make(map[string]chan struct{}),
+		pool:       http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	t.SetLogLevel(minLogLevel)
+	return t
+}
+
+func (t *TenantTransport) Configure(options sentry.ClientOptions) {
+
+	if options.HTTPTransport == nil {
+		options.HTTPTransport = t.pool
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.options = options
+	t.fallback = nil
+	if options.Dsn != "" {
+		t.fallback = NewSentryTransport(DEBUG)
+		t.fallback.Configure(options)
+	}
+}
+
+func (t *TenantTransport) SendEvent(ev *sentry.Event) {
+
+	t.Call(func(ev *sentry.Event) {
+
+		tr := t.transport(t.tenant(ev))
+		if tr != nil {
+			tr.SendEvent(ev)
+		}
+	}, ev)
+}
+
+// tenant of an event, empty if it has none
+func (t *TenantTransport) tenant(ev *sentry.Event) string {
+
+	if tenant, ok := ev.Tags[t.TenantKey]; ok {
+		return tenant
+	}
+	if v, ok := Field(ev, t.TenantKey); ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// transport of a tenant, created on first use, the fallback if the tenant has
+// no DSN. The DSN is looked up once at a time per tenant, without holding t.mu,
+// so a slow lookup delays the events of its tenant only.
+func (t *TenantTransport) transport(tenant string) *SentryTransport {
+
+	t.mu.Lock()
+
+	if tenant == "" {
+		defer t.mu.Unlock()
+		return t.fallback
+	}
+
+	ts := now()
+	t.evictIdle(ts)
+
+	for {
+		if e, ok := t.clients[tenant]; ok {
+			c := e.Value.(*tenantClient)
+			c.lastUsed = ts
+			t.lru.MoveToFront(e)
+			defer t.mu.Unlock()
+			return c.transport
+		}
+		if retry, ok := t.failed[tenant]; ok && ts.Before(retry) {
+			defer t.mu.Unlock()
+			return t.fallback
+		}
+		lookup, running := t.lookups[tenant]
+		if !running {
+			break
+		}
+		t.mu.Unlock()
+		<-lookup
+		t.mu.Lock()
+	}
+
+	lookup := make(chan struct{})
+	t.lookups[tenant] = lookup
+	t.mu.Unlock()
+
+	dsn, err := t.DSN(tenant)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.lookups, tenant)
+	close(lookup)
+
+	if err != nil || dsn == "" {
+		if err != nil {
+			notice("no DSN for tenant %q: %v", tenant, err)
+		} else {
+			notice("no DSN for tenant %q", tenant)
+		}
+		t.failed[tenant] = ts.Add(t.RetryAfter)
+		return t.fallback
+	}
+	delete(t.failed, tenant)
+
+	options := t.options
+	options.Dsn = dsn
+	tr := NewSentryTransport(DEBUG)
+	tr.Configure(options)
+
+	t.clients[tenant] = t.lru.PushFront(&tenantClient{tenant: tenant, transport: tr, lastUsed: ts})
+	for t.MaxClients > 0 && t.lru.Len() > t.MaxClients {
+		t.evict(t.lru.Back())
+	}
+	return tr
+}
+
+// evicts the transports idle for IdleTimeout, the least recently used last
+func (t *TenantTransport) evictIdle(ts time.Time) {
+
+	if t.IdleTimeout <= 0 {
+		return
+	}
+	for e := t.lru.Back(); e != nil && ts.Sub(e.Value.(*tenantClient).lastUsed) > t.IdleTimeout; e = t.lru.Back() {
+		t.evict(e)
+	}
+}
+
+func (t *TenantTransport) evict(e *list.Element) {
+
+	c := t.lru.Remove(e).(*tenantClient)
+	delete(t.clients, c.tenant)
+	c.transport.Flush(FlushTimeout)
+}
+
+// Flush flushes the transports of all live tenants
+func (t *TenantTransport) Flush(timeout time.Duration) bool {
+
+	t.mu.Lock()
+	transports := make([]*SentryTransport, 0, t.lru.Len()+1)
+	for e := t.lru.Front(); e != nil; e = e.Next() {
+		transports = append(transports, e.Value.(*tenantClient).transport)
+	}
+	if t.fallback != nil {
+		transports = append(transports, t.fallback)
+	}
+	t.mu.Unlock()
+
+	ok := true
+	for _, tr := range transports {
+		ok = tr.Flush(timeout) && ok
+	}
+	return ok
+}
+
+// Tenants returns the number of live tenant transports
+func (t *TenantTransport) Tenants() int {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lru.Len()
+}
@@ -0,0 +1,66 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTenantTransportCachesFailedLookups(t *testing.T) {
+
+	quiet(t)
+	var calls int32
+	tr := NewTenantTransport(func(string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		return "", errors.New("unknown tenant")
+	}, 10, DEBUG)
+
+	for i := 0; i < 5; i++ {
+		if got := tr.transport("gone"); got != nil {
+			t.Fatalf("transport = %v, want the fallback, nil without destination DSN", got)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("DSN called %d times, want once until RetryAfter passed", n)
+	}
+}
+
+func TestTenantTransportLooksUpWithoutLock(t *testing.T) {
+
+	quiet(t)
+	release := make(chan struct{})
+	defer close(release)
+	tr := NewTenantTransport(func(tenant string) (string, error) {
+		if tenant == "slow" {
+			<-release
+		}
+		return "", nil
+	}, 10, DEBUG)
+
+	go tr.transport("slow")
+	time.Sleep(10 * time.Millisecond) // the slow lookup is running
+
+	done := make(chan struct{})
+	go func() {
+		tr.transport("fast")
+		tr.Tenants()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a slow DSN lookup blocks other tenants")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TraceParent is the W3C trace context of a request, see
+// https://www.w3.org/TR/trace-context/
+type TraceParent struct {
+	TraceID  string // 32 lowercase hex chars
+	ParentID string // 16 lowercase hex chars, span ID of the caller
+	Flags    byte   // bit 0 set if the caller sampled the trace
+	State    string // vendor specific tracestate header, passed on unchanged
+}
+
+type traceParentKey struct{}
+
+var errTraceparent = errors.New("invalid traceparent header")
+
+// ParseTraceparent parses the traceparent and tracestate headers of a request,
+// no tracing SDK is needed
+func ParseTraceparent(traceparent string, tracestate string) (TraceParent, error) {
+
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return TraceParent{}, errTraceparent
+	}
+	version, traceID, parentID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	switch {
+	case !isHex(version, 2) || version == "ff",
+		version == "00" && len(parts) != 4, // later versions may append fields
+		!isHex(traceID, 32) || traceID == strings.Repeat("0", 32),
+		!isHex(parentID, 16) || parentID == strings.Repeat("0", 16),
+		!isHex(flags, 2):
+		return TraceParent{}, errTraceparent
+	}
+
+	f, _ := hex.DecodeString(flags)
+	return TraceParent{TraceID: traceID, ParentID: parentID, Flags: f[0], State: tracestate}, nil
+}
+
+// lowercase hex string of length n
+func isHex(s string, n int) bool {
+
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// WithTraceParent returns a copy of ctx carrying tp: events logged with the Ctx
+// variants get the fields trace_id and parent_id, and a Sentry trace context
+// linking them to the trace
+func WithTraceParent(ctx context.Context, tp TraceParent) context.Context {
+
+	ctx = context.WithValue(ctx, traceParentKey{}, tp)
+	return WithContext(ctx, Set("trace_id", tp.TraceID).Set("parent_id", tp.ParentID))
+}
+
+// TraceParentFromContext returns the trace context carried by ctx
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+
+	if ctx == nil {
+		return TraceParent{}, false
+	}
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
+
+// TraceparentMiddleware carries the W3C trace context of incoming requests in
+// the request context, see WithTraceParent. Invalid headers are ignored.
+func TraceparentMiddleware(next http.Handler) http.Handler {
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		tp, err := ParseTraceparent(r.Header.Get("traceparent"), r.Header.Get("tracestate"))
+		if err == nil {
+			r = r.WithContext(WithTraceParent(r.Context(), tp))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sets the sentry trace context of r from the trace context carried by ctx,
+// a trace context set by a tracing SDK is kept
+func applyTraceParent(ctx context.Context, r *Record) {
+
+	tp, ok := TraceParentFromContext(ctx)
+	if !ok {
+		return
+	}
+	if _, exists := r.Contexts["trace"]; exists {
+		return
+	}
+	ev := r.sentryPart()
+	if ev.Contexts == nil {
+		ev.Contexts = make(map[string]interface{})
+	}
+	ev.Contexts["trace"] = map[string]interface{}{
+		"trace_id":       tp.TraceID,
+		"parent_span_id": tp.ParentID,
+	}
+}
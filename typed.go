@@ -0,0 +1,92 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"math"
+	"time"
+)
+
+// Typed setters store values in the form every transport writes the same way:
+// durations as strings like "1.2s", times as RFC 3339 strings in UTC and errors
+// as their message. The argument types catch wrong values at compile time, and
+// the values are stored unboxed until the event is logged:
+//
+//	senlog.Cxt("job").SetStr("name", name).SetDur("took", took).INF("Done")
+
+// how a contextField stores its value
+type fieldKind uint8
+
+const (
+	anyField   fieldKind = iota // value
+	strField                    // str
+	intField                    // num, int64 bits
+	floatField                  // num, float64 bits
+	boolField                   // num, 1 for true
+	durField                    // num, int64 bits of a time.Duration
+)
+
+// value of f as logged
+func (f contextField) logged() interface{} {
+
+	switch f.kind {
+	case strField:
+		return f.str
+	case intField:
+		return int64(f.num)
+	case floatField:
+		return math.Float64frombits(f.num)
+	case boolField:
+		return f.num == 1
+	case durField:
+		return time.Duration(f.num).String()
+	}
+	return f.value
+}
+
+func (x *Context) SetStr(k string, v string) *Context {
+	return x.set(contextField{key: k, kind: strField, str: v})
+}
+
+func (x *Context) SetInt(k string, v int64) *Context {
+	return x.set(contextField{key: k, kind: intField, num: uint64(v)})
+}
+
+func (x *Context) SetFloat(k string, v float64) *Context {
+	return x.set(contextField{key: k, kind: floatField, num: math.Float64bits(v)})
+}
+
+func (x *Context) SetBool(k string, v bool) *Context {
+
+	f := contextField{key: k, kind: boolField}
+	if v {
+		f.num = 1
+	}
+	return x.set(f)
+}
+
+// SetDur sets a duration as a string like "1.2s"
+func (x *Context) SetDur(k string, v time.Duration) *Context {
+	return x.set(contextField{key: k, kind: durField, num: uint64(v)})
+}
+
+// SetTime sets a time as RFC 3339 string in UTC, with fractional seconds if any
+func (x *Context) SetTime(k string, v time.Time) *Context {
+	return x.SetStr(k, v.UTC().Format(time.RFC3339Nano))
+}
+
+// SetErr sets the message of an error, nil if err is nil
+func (x *Context) SetErr(k string, err error) *Context {
+	if err == nil {
+		return x.Set(k, nil)
+	}
+	return x.SetStr(k, err.Error())
+}
@@ -0,0 +1,55 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTypedSettersLogConsistentValues(t *testing.T) {
+
+	at := time.Date(2022, 3, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600))
+	x := Cxt("job").SetStr("name", "nightly").SetInt("count", -3).SetFloat("ratio", 0.5).
+		SetBool("ok", true).SetDur("took", 1200*time.Millisecond).SetTime("at", at).
+		SetErr("err", errors.New("boom")).SetErr("none", nil)
+
+	want := map[string]interface{}{
+		"name":  "nightly",
+		"count": int64(-3),
+		"ratio": 0.5,
+		"ok":    true,
+		"took":  "1.2s",
+		"at":    "2022-03-04T04:06:07Z",
+		"err":   "boom",
+		"none":  nil,
+	}
+	got := x.contexts()["job"].(map[string]interface{})
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %#v, want %#v", k, got[k], v)
+		}
+	}
+}
+
+func TestTypedSettersDontBox(t *testing.T) {
+
+	base := Cxt("job")
+	name := string([]byte("not a constant"))
+
+	boxed := testing.AllocsPerRun(100, func() { base.Set("name", name).Set("count", int64(1<<40)) })
+	typed := testing.AllocsPerRun(100, func() { base.SetStr("name", name).SetInt("count", 1<<40) })
+
+	if typed >= boxed {
+		t.Errorf("typed setters allocate %v times, Set %v times", typed, boxed)
+	}
+}
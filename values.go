@@ -0,0 +1,109 @@
+/*
+BSD 2-Clause License
+
+Copyright (c) 2022, Muhammad Ejaz Mughal
+All rights reserved.
+
+Complete license aggreement:
+https://github.com/ejazmughal/senlog/blob/main/LICENSE
+*/
+
+package senlog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// LogValuer is implemented by types controlling their own log representation,
+// e.g. to hide secrets or log a summary of a large struct. LogValue is called
+// when the event is built, its result is written by all transports:
+//
+//	func (u User) LogValue() interface{} {
+//		return map[string]interface{}{"id": u.ID, "role": u.Role}
+//	}
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// LogValue calls of one value, a LogValuer returning itself stops there
+const maxLogValueDepth = 10
+
+// field values as logged: LogValuer values are replaced by their LogValue.
+// Values without own JSON encoding are replaced by their text of
+// encoding.TextMarshaler or fmt.Stringer. Nested maps and slices are resolved
+// too. The group maps of contexts are shared with the Context and not changed,
+// groups with replaced values are copied.
+func resolveValues(contexts map[string]interface{}) map[string]interface{} {
+
+	for name, fields := range contexts {
+		if r, changed := resolveValue(fields, 0); changed {
+			contexts[name] = r
+		}
+	}
+	return contexts
+}
+
+// the logged form of v, and whether it differs from v
+func resolveValue(v interface{}, depth int) (r interface{}, changed bool) {
+
+	defer func() {
+		if p := recover(); p != nil { // e.g. String of a nil pointer
+			r, changed = fmt.Sprintf("!PANIC: %v", p), true
+		}
+	}()
+
+	switch t := v.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v, false
+	case LogValuer:
+		if depth >= maxLogValueDepth {
+			return v, false
+		}
+		r, _ := resolveValue(t.LogValue(), depth+1)
+		return r, true
+	case json.Marshaler:
+		return v, false // e.g. time.Time, its JSON is its representation
+	case encoding.TextMarshaler:
+		text, err := t.MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!ERROR: %v", err), true
+		}
+		return string(text), true
+	case fmt.Stringer:
+		return t.String(), true
+	case map[string]interface{}:
+		var c map[string]interface{}
+		for k, e := range t {
+			if r, ok := resolveValue(e, depth); ok {
+				if c == nil {
+					c = make(map[string]interface{}, len(t))
+					for k, e := range t {
+						c[k] = e
+					}
+				}
+				c[k] = r
+			}
+		}
+		if c == nil {
+			return v, false
+		}
+		return c, true
+	case []interface{}:
+		var c []interface{}
+		for i, e := range t {
+			if r, ok := resolveValue(e, depth); ok {
+				if c == nil {
+					c = append([]interface{}(nil), t...)
+				}
+				c[i] = r
+			}
+		}
+		if c == nil {
+			return v, false
+		}
+		return c, true
+	}
+	return v, false
+}